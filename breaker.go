@@ -0,0 +1,187 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrCircuitOpen is the error of relays skipped because their circuit breaker is open
+var ErrCircuitOpen = errors.New("relay skipped: circuit breaker open")
+
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // BreakerClosed sends requests to the relay
+	BreakerOpen                         // BreakerOpen skips the relay until the cooldown passed
+	BreakerHalfOpen                     // BreakerHalfOpen lets a single probe request through to decide between open and closed
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerOptions configures the circuit breakers of a BatchRelayClient. A relay's breaker opens after
+// FailureThreshold consecutive failed requests. Once Cooldown passed a single probe request is let through, which
+// closes the breaker if it succeeds and opens it again if it fails. Requests that reached the relay count as
+// successful even if it answered with a JSON-RPC error, whatever its HTTP status, those failing with a *StatusError
+// do not. Requests that ran into an adaptive timeout or the deadline of BatchSendBundleBefore fail, those whose
+// context was canceled or timed out by the caller are not counted.
+type BreakerOptions struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	// OnStateChange is called on every state transition of a relay's breaker, may be nil
+	OnStateChange func(name string, from, to BreakerState)
+}
+
+type breaker struct {
+	state      BreakerState
+	failures   int       // failures is the number of consecutive failed requests
+	openedAt   time.Time // openedAt is when the breaker last opened
+	probeStart time.Time // probeStart is when the half-open probe was let through, zero if there is none in flight
+}
+
+type breakerTransition struct {
+	name     string
+	from, to BreakerState
+}
+
+// SetCircuitBreaker enables a circuit breaker per relay client with opts, nil to disable them. Breakers start closed.
+func (r *BatchRelayClient) SetCircuitBreaker(opts *BreakerOptions) {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	r.breakerOpts = opts
+	r.breakers = make(map[string]*breaker)
+}
+
+// BreakerState returns the circuit breaker state of the relay client called name, BreakerClosed if circuit
+// breakers are disabled
+func (r *BatchRelayClient) BreakerState(name string) BreakerState {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	if b, ok := r.breakers[name]; ok {
+		return b.state
+	}
+	return BreakerClosed
+}
+
+// BreakerStates returns the circuit breaker state of every connected relay client by name
+func (r *BatchRelayClient) BreakerStates() (states map[string]BreakerState) {
+	states = make(map[string]BreakerState, len(r.relayClients))
+	for _, client := range r.relayClients {
+		states[client.Name()] = r.BreakerState(client.Name())
+	}
+	return
+}
+
+// allow returns ErrCircuitOpen if requests to the relay client called name must be skipped. An open breaker whose
+// cooldown passed turns half-open and lets the caller through as its probe.
+func (r *BatchRelayClient) allow(name string) (retErr error) {
+	var transitions []breakerTransition
+	defer func() { r.notify(transitions) }()
+
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	opts := r.breakerOpts
+	if opts == nil {
+		return
+	}
+	b, ok := r.breakers[name]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < opts.Cooldown {
+			return ErrCircuitOpen
+		}
+		transitions = append(transitions, b.transition(name, BreakerHalfOpen))
+		b.probeStart = now
+	case BreakerHalfOpen:
+		// a probe that never completed, e.g. because it was never sent, is replaced after another cooldown
+		if !b.probeStart.IsZero() && now.Sub(b.probeStart) < opts.Cooldown {
+			return ErrCircuitOpen
+		}
+		b.probeStart = now
+	}
+	return
+}
+
+// recordBreaker updates the circuit breaker of the relay client called name with the outcome of a request sent
+// with the caller's ctx
+func (r *BatchRelayClient) recordBreaker(ctx context.Context, name string, resp SendBundleResponse) {
+	var transitions []breakerTransition
+	defer func() { r.notify(transitions) }()
+
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	opts := r.breakerOpts
+	if opts == nil {
+		return
+	}
+	b, ok := r.breakers[name]
+	if !ok {
+		b = &breaker{}
+		r.breakers[name] = b
+	}
+
+	if resp.Error == nil {
+		b.failures = 0
+		b.probeStart = time.Time{}
+		if b.state != BreakerClosed {
+			transitions = append(transitions, b.transition(name, BreakerClosed))
+		}
+		return
+	}
+	if ctx.Err() != nil || errors.Is(resp.Error, context.Canceled) || errors.Is(resp.Error, ErrRelaySkipped) ||
+		errors.Is(resp.Error, ErrRelayUnhealthy) || errors.Is(resp.Error, ErrCircuitOpen) || errors.Is(resp.Error, ErrRateLimited) {
+		if b.state == BreakerHalfOpen {
+			b.probeStart = time.Time{}
+		}
+		return
+	}
+
+	b.failures++
+	b.probeStart = time.Time{}
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= opts.FailureThreshold) {
+		b.openedAt = time.Now()
+		transitions = append(transitions, b.transition(name, BreakerOpen))
+	}
+}
+
+func (b *breaker) transition(name string, to BreakerState) (t breakerTransition) {
+	t = breakerTransition{
+		name: name,
+		from: b.state,
+		to:   to,
+	}
+	b.state = to
+	return
+}
+
+func (r *BatchRelayClient) notify(transitions []breakerTransition) {
+	if len(transitions) == 0 {
+		return
+	}
+	r.statsMu.Lock()
+	opts := r.breakerOpts
+	r.statsMu.Unlock()
+	if opts == nil || opts.OnStateChange == nil {
+		return
+	}
+	for _, t := range transitions {
+		opts.OnStateChange(t.name, t.from, t.to)
+	}
+}
@@ -0,0 +1,178 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestBatchRelayClient_SetCircuitBreaker(t *testing.T) {
+	var down int32 = 1
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&down) == 1 {
+			panic(http.ErrAbortHandler)
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	relay, _ := NewRelayClient(pkey, "relay", server.URL, "")
+	batch, _ := NewBatchRelayClientFromRelayClients(relay)
+
+	transitions := make([]string, 0)
+	batch.SetCircuitBreaker(&BreakerOptions{
+		FailureThreshold: 2,
+		Cooldown:         50 * time.Millisecond,
+		OnStateChange: func(name string, from, to BreakerState) {
+			transitions = append(transitions, fmt.Sprintf("%s:%s->%s", name, from, to))
+		},
+	})
+
+	send := func() error {
+		return batch.BatchSendBundleContext(context.Background(), Bundle{})["relay"].Error
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := send(); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("send %d error = %v, want a request failure", i, err)
+		}
+	}
+	if state := batch.BreakerState("relay"); state != BreakerOpen {
+		t.Fatalf("BreakerState() = %v, want %v", state, BreakerOpen)
+	}
+	if err := send(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("send while open error = %v, want ErrCircuitOpen", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("requests = %v, want 2, open breaker must not send", got)
+	}
+
+	// the half-open probe fails and opens the breaker again
+	time.Sleep(60 * time.Millisecond)
+	if err := send(); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("failed probe error = %v, want a request failure", err)
+	}
+	if state := batch.BreakerState("relay"); state != BreakerOpen {
+		t.Errorf("BreakerState() after failed probe = %v, want %v", state, BreakerOpen)
+	}
+
+	// the half-open probe succeeds and closes the breaker
+	atomic.StoreInt32(&down, 0)
+	time.Sleep(60 * time.Millisecond)
+	if err := send(); err != nil {
+		t.Errorf("successful probe error = %v", err)
+	}
+	if states := batch.BreakerStates(); states["relay"] != BreakerClosed {
+		t.Errorf("BreakerStates() = %v, want relay closed", states)
+	}
+
+	want := []string{
+		"relay:closed->open",
+		"relay:open->half-open",
+		"relay:half-open->open",
+		"relay:open->half-open",
+		"relay:half-open->closed",
+	}
+	if fmt.Sprint(transitions) != fmt.Sprint(want) {
+		t.Errorf("transitions = %v, want %v", transitions, want)
+	}
+}
+
+func TestBatchRelayClient_SetCircuitBreaker_deadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	relay, _ := NewRelayClient(pkey, "relay", server.URL, "")
+	batch, _ := NewBatchRelayClientFromRelayClients(relay)
+	batch.SetCircuitBreaker(&BreakerOptions{FailureThreshold: 1, Cooldown: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := batch.BatchSendBundleContext(ctx, Bundle{})["relay"].Error; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("send error = %v, want context.DeadlineExceeded", err)
+	}
+	if state := batch.BreakerState("relay"); state != BreakerClosed {
+		t.Errorf("BreakerState() after the caller's deadline = %v, want %v", state, BreakerClosed)
+	}
+}
+
+func TestBatchRelayClient_SetCircuitBreaker_httpStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	relay, _ := NewRelayClient(pkey, "relay", server.URL, "")
+	batch, _ := NewBatchRelayClientFromRelayClients(relay)
+	batch.SetCircuitBreaker(&BreakerOptions{FailureThreshold: 1, Cooldown: time.Minute})
+
+	var statusErr *StatusError
+	if err := batch.BatchSendBundleContext(context.Background(), Bundle{})["relay"].Error; !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("send error = %v, want a 503 *StatusError", err)
+	}
+	if state := batch.BreakerState("relay"); state != BreakerOpen {
+		t.Errorf("BreakerState() after a 503 = %v, want %v", state, BreakerOpen)
+	}
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"nonce too low"}}`))
+	}))
+	defer rejecting.Close()
+	relay, _ = NewRelayClient(pkey, "relay", rejecting.URL, "")
+	batch, _ = NewBatchRelayClientFromRelayClients(relay)
+	batch.SetCircuitBreaker(&BreakerOptions{FailureThreshold: 1, Cooldown: time.Minute})
+	if err := batch.BatchSendBundleContext(context.Background(), Bundle{})["relay"].Error; err != nil {
+		t.Fatalf("send error = %v, want the JSON-RPC error in the response", err)
+	}
+	if state := batch.BreakerState("relay"); state != BreakerClosed {
+		t.Errorf("BreakerState() after a JSON-RPC error with a 400 = %v, want %v", state, BreakerClosed)
+	}
+}
+
+func TestBatchRelayClient_SetCircuitBreaker_timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	relay, _ := NewRelayClient(pkey, "relay", server.URL, "")
+	batch, _ := NewBatchRelayClientFromRelayClients(relay)
+	batch.SetAdaptive(&AdaptiveOptions{TimeoutPercentile: 0.99, MinSamples: 1, MaxTimeout: 10 * time.Millisecond})
+	batch.SetCircuitBreaker(&BreakerOptions{FailureThreshold: 2, Cooldown: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if err := batch.BatchSendBundleContext(context.Background(), Bundle{})["relay"].Error; !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("send %d error = %v, want context.DeadlineExceeded", i, err)
+		}
+	}
+	if state := batch.BreakerState("relay"); state != BreakerOpen {
+		t.Errorf("BreakerState() after adaptive timeouts = %v, want %v", state, BreakerOpen)
+	}
+
+	batch.SetAdaptive(nil)
+	batch.SetCircuitBreaker(&BreakerOptions{FailureThreshold: 1, Cooldown: time.Minute})
+	batch.BatchSendBundleBefore(Bundle{}, time.Now().Add(50*time.Millisecond))
+	if state := batch.BreakerState("relay"); state != BreakerOpen {
+		t.Errorf("BreakerState() after the BatchSendBundleBefore deadline = %v, want %v", state, BreakerOpen)
+	}
+}
@@ -0,0 +1,211 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DryRunRecord is a request that was not sent to a relay because of a dry run or shadow mode
+type DryRunRecord struct {
+	Relay           string // Relay is the name of the relay client or nested relay the request was meant for
+	Method          string // Method is eth_sendBundle or eth_cancelBundle
+	Bundle          Bundle // Bundle is the bundle of an eth_sendBundle request
+	ReplacementUUID string // ReplacementUUID is the replacementUUID of an eth_cancelBundle request
+}
+
+// DryRunOptions configures a DryRunRelay
+type DryRunOptions struct {
+	// Simulate simulates every bundle on the wrapped relay, the simulation is then the response of every relay
+	// client. Without it responses are empty.
+	Simulate          bool
+	SimulationOptions *SimulationOptions
+
+	// OnRecord is called with every request that was not sent, may be nil
+	OnRecord func(DryRunRecord)
+}
+
+// DryRunRelay wraps a Relay and never sends or cancels bundles on it. Sent bundles are simulated instead if
+// Simulate is set, simulations, statistics and names are those of the wrapped relay. Responses are marked DryRun.
+type DryRunRelay struct {
+	relay Relay
+	opts  DryRunOptions
+}
+
+var _ Relay = (*DryRunRelay)(nil)
+
+// NewDryRunRelay creates a dry run of relay
+func NewDryRunRelay(relay Relay, opts DryRunOptions) (d *DryRunRelay, retErr error) {
+	if relay == nil {
+		retErr = errors.New("must provide a relay")
+		return
+	}
+	d = &DryRunRelay{
+		relay: relay,
+		opts:  opts,
+	}
+	return
+}
+
+func (d *DryRunRelay) Name() string { return d.relay.Name() }
+
+// Send records b and simulates it if Simulate is set, returning a DryRun response for every relay client of the
+// wrapped relay
+func (d *DryRunRelay) Send(ctx context.Context, b Bundle) map[string]SendBundleResponse {
+	d.record(DryRunRecord{
+		Relay:  d.relay.Name(),
+		Method: "eth_sendBundle",
+		Bundle: b,
+	})
+
+	var resp SendBundleResponse
+	if d.opts.Simulate {
+		resp = d.relay.Simulate(ctx, b, d.opts.SimulationOptions)
+	}
+	resp.DryRun = true
+	return d.responses(resp)
+}
+
+// Simulate simulates b on the wrapped relay
+func (d *DryRunRelay) Simulate(ctx context.Context, b Bundle, opts *SimulationOptions) SendBundleResponse {
+	return d.relay.Simulate(ctx, b, opts)
+}
+
+// Cancel records the cancellation, returning a DryRun response for every relay client of the wrapped relay
+func (d *DryRunRelay) Cancel(ctx context.Context, replacementUUID string) map[string]SendBundleResponse {
+	d.record(DryRunRecord{
+		Relay:           d.relay.Name(),
+		Method:          "eth_cancelBundle",
+		ReplacementUUID: replacementUUID,
+	})
+	return d.responses(SendBundleResponse{DryRun: true})
+}
+
+// Stats returns the statistics of the wrapped relay
+func (d *DryRunRelay) Stats() map[string]RelayStats {
+	return d.relay.Stats()
+}
+
+func (d *DryRunRelay) record(record DryRunRecord) {
+	if d.opts.OnRecord != nil {
+		d.opts.OnRecord(record)
+	}
+}
+
+// responses returns resp for every relay client of the wrapped relay by name
+func (d *DryRunRelay) responses(resp SendBundleResponse) (resps map[string]SendBundleResponse) {
+	stats := d.relay.Stats()
+	resps = make(map[string]SendBundleResponse, len(stats))
+	for name := range stats {
+		resps[name] = resp
+	}
+	if len(resps) == 0 {
+		resps[d.relay.Name()] = resp
+	}
+	return
+}
+
+// dryRun records the request of method with payload in the journal of r, marked DryRun, without sending it
+func (r *RelayClient) dryRun(method string, payload []byte) (resp SendBundleResponse) {
+	resp = SendBundleResponse{
+		DryRun: true,
+	}
+	if r.journal != nil {
+		r.journal.record(r, method, time.Now(), payload, resp)
+	}
+	return
+}
+
+// ShadowOptions configures the shadow mode of a BatchRelayClient
+type ShadowOptions struct {
+	// Live are the names of the relay clients and nested relays that bundles are sent to and canceled on, the
+	// others are shadowed
+	Live []string
+
+	// OnShadow is called with every request not sent to a shadowed relay, may be nil
+	OnShadow func(DryRunRecord)
+}
+
+// SetShadow makes r only send bundles to and cancel bundles on the relays in opts.Live, nil to use all relays.
+// Shadowed relay clients get a response marked DryRun, their requests are signed and recorded in their journal but
+// not sent, and are not counted in statistics or circuit breakers. Shadowed nested relays are run as a DryRunRelay.
+func (r *BatchRelayClient) SetShadow(opts *ShadowOptions) {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	r.shadow = opts
+	r.live = nil
+	if opts != nil {
+		r.live = make(map[string]bool, len(opts.Live))
+		for _, name := range opts.Live {
+			r.live[name] = true
+		}
+	}
+}
+
+// shadowed returns the shadow options of r and whether the relay called name is shadowed
+func (r *BatchRelayClient) shadowed(name string) (opts *ShadowOptions, shadowed bool) {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	return r.shadow, r.shadow != nil && !r.live[name]
+}
+
+// shadowRequest records a request of client that is not sent because client is shadowed
+func (r *BatchRelayClient) shadowRequest(opts *ShadowOptions, client *RelayClient, record DryRunRecord, payload []byte, err error) SendBundleResponse {
+	if err != nil {
+		return SendBundleResponse{
+			Error:  err,
+			DryRun: true,
+		}
+	}
+	if opts.OnShadow != nil {
+		opts.OnShadow(record)
+	}
+	return client.dryRun(record.Method, payload)
+}
+
+// shadowBundle signs b for client and records it without sending it
+func (r *BatchRelayClient) shadowBundle(opts *ShadowOptions, client *RelayClient, b Bundle) SendBundleResponse {
+	payload, err := client.prepareBundlePayload(b, "eth_sendBundle")
+	return r.shadowRequest(opts, client, DryRunRecord{
+		Relay:  client.Name(),
+		Method: "eth_sendBundle",
+		Bundle: b,
+	}, payload, err)
+}
+
+// cancelBundleOn cancels the bundles sent with replacementUUID on client, or records the cancellation if client is
+// shadowed
+func (r *BatchRelayClient) cancelBundleOn(ctx context.Context, client *RelayClient, replacementUUID string) SendBundleResponse {
+	opts, shadowed := r.shadowed(client.Name())
+	if !shadowed {
+		return client.cancelBundle(ctx, replacementUUID)
+	}
+
+	var payload []byte
+	err := errors.New("must provide a replacementUUID")
+	if replacementUUID != "" {
+		payload, err = client.prepareCancelBundlePayload(replacementUUID)
+	}
+	return r.shadowRequest(opts, client, DryRunRecord{
+		Relay:           client.Name(),
+		Method:          "eth_cancelBundle",
+		ReplacementUUID: replacementUUID,
+	}, payload, err)
+}
+
+// nested returns the nested relays of r, with the shadowed ones wrapped in a DryRunRelay
+func (r *BatchRelayClient) nested() []Relay {
+	relays := make([]Relay, 0, len(r.relays))
+	for _, relay := range r.relays {
+		if opts, shadowed := r.shadowed(relay.Name()); shadowed {
+			relay = &DryRunRelay{
+				relay: relay,
+				opts: DryRunOptions{
+					OnRecord: opts.OnShadow,
+				},
+			}
+		}
+		relays = append(relays, relay)
+	}
+	return relays
+}
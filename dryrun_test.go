@@ -0,0 +1,130 @@
+package flashbots
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestDryRunRelay(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65","results":[]}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	client, _ := NewRelayClient(pkey, "relay", server.URL, server.URL)
+
+	var records []DryRunRecord
+	dry, err := NewDryRunRelay(client, DryRunOptions{
+		OnRecord: func(record DryRunRecord) { records = append(records, record) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resps := dry.Send(context.Background(), Bundle{BlockNumber: "0x1"})
+	if resp, ok := resps["relay"]; !ok || !resp.DryRun || resp.ResponseBytes != nil {
+		t.Errorf("Send() = %+v, want an empty dry run response", resps)
+	}
+	dry.Cancel(context.Background(), "uuid")
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("dry run sent %d requests", n)
+	}
+	if len(records) != 2 || records[0].Method != "eth_sendBundle" || records[0].Bundle.BlockNumber != "0x1" ||
+		records[1].Method != "eth_cancelBundle" || records[1].ReplacementUUID != "uuid" {
+		t.Errorf("records = %+v", records)
+	}
+
+	simulating, _ := NewDryRunRelay(client, DryRunOptions{Simulate: true})
+	resp := simulating.Send(context.Background(), Bundle{BlockNumber: "0x1"})["relay"]
+	if !resp.DryRun || resp.Error != nil || len(resp.ResponseBytes) == 0 {
+		t.Errorf("Send() with Simulate = %+v, want a dry run simulation", resp)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("dry run with Simulate sent %d requests, want 1 simulation", n)
+	}
+	if s := client.Stats()["relay"]; s.Requests != 0 {
+		t.Errorf("dry run was counted in stats: %+v", s)
+	}
+}
+
+func TestBatchRelayClient_SetShadow(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	live, _ := NewRelayClient(pkey, "live", server.URL, "")
+	shadow, _ := NewRelayClient(pkey, "shadow", server.URL, "")
+	mock := &recordingRelay{name: "mock"}
+	batch, _ := NewBatchRelay("batch", live, shadow, mock)
+
+	journal, err := NewJournal(filepath.Join(t.TempDir(), "journal.jsonl"), JournalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	shadow.SetJournal(journal)
+
+	var records []DryRunRecord
+	batch.SetShadow(&ShadowOptions{
+		Live:     []string{"live"},
+		OnShadow: func(record DryRunRecord) { records = append(records, record) },
+	})
+	batch.SetCircuitBreaker(&BreakerOptions{FailureThreshold: 1})
+
+	resps := batch.BatchSendBundle(Bundle{})
+	if resps["live"].DryRun || resps["live"].Error != nil {
+		t.Errorf("live relay response = %+v", resps["live"])
+	}
+	for _, name := range []string{"shadow", "mock"} {
+		if !resps[name].DryRun || resps[name].Error != nil {
+			t.Errorf("%s relay response = %+v, want a dry run", name, resps[name])
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+	if len(mock.sent) != 0 {
+		t.Error("bundle was sent to a shadowed nested relay")
+	}
+	if len(records) != 2 {
+		t.Errorf("got %d shadow records, want 2: %+v", len(records), records)
+	}
+	if s := batch.Stats()["shadow"]; s.Requests != 0 {
+		t.Errorf("shadowed relay was counted in stats: %+v", s)
+	}
+
+	batch.BatchCancelBundle("uuid")
+	if n := atomic.LoadInt32(&requests); n != 2 || len(mock.canceled) != 0 {
+		t.Errorf("cancel sent %d requests, nested relay canceled %v", n-1, mock.canceled)
+	}
+
+	journal.Close()
+	var entries []JournalEntry
+	if err := ReplayJournal(journal.path, func(entry JournalEntry) error {
+		entries = append(entries, entry)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || !entries[0].DryRun || entries[0].Method != "eth_sendBundle" || entries[1].Method != "eth_cancelBundle" {
+		t.Errorf("journal entries = %+v, want the shadowed send and cancel", entries)
+	}
+
+	batch.SetShadow(nil)
+	batch.BatchSendBundle(Bundle{})
+	if n := atomic.LoadInt32(&requests); n != 4 || len(mock.sent) != 1 {
+		t.Errorf("after disabling shadow mode sent %d requests, nested relay got %d bundles", n-2, len(mock.sent))
+	}
+}
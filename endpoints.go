@@ -0,0 +1,177 @@
+package flashbots
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// NewRelayClientWithEndpoints creates a new relay client with several endpoints per role. Requests go to the first
+// endpoint of their role and fail over to the next one in order when an endpoint errors.
+// signingPrivateKey:    the key used to sign bundles (this can be any valid private key)
+// mainEndpoints:        the relay server endpoints used for sending bundles, in order of preference
+// simulationEndpoints:  the relay server endpoints used for bundle simulation, in order of preference, may be empty
+func NewRelayClientWithEndpoints(signingPrivateKey *ecdsa.PrivateKey, name string, mainEndpoints, simulationEndpoints []string) (r *RelayClient, retErr error) {
+	if signingPrivateKey == nil {
+		retErr = errors.New("must provide a signingPrivateKey")
+		return
+	}
+	if len(mainEndpoints) == 0 {
+		retErr = errors.New("must provide a main endpoint")
+		return
+	}
+	r = &RelayClient{
+		name:                 name,
+		signingPrivateKey:    signingPrivateKey,
+		signingPublicAddress: crypto.PubkeyToAddress(signingPrivateKey.PublicKey),
+		mainEndpoints:        append([]string{}, mainEndpoints...),
+		simulationEndpoints:  append([]string{}, simulationEndpoints...),
+		stats:                &clientStats{},
+	}
+
+	return
+}
+
+func (r RelayClient) MainEndpoints() []string { return append([]string{}, r.mainEndpoints...) }
+func (r RelayClient) SimulationEndpoints() []string {
+	return append([]string{}, r.simulationEndpoints...)
+}
+func (r RelayClient) HedgeDelay() time.Duration { return r.hedgeDelay }
+
+// SetHedgeDelay makes requests that got no answer from an endpoint within delay also go to the next endpoint of
+// their role, the first successful answer wins. 0 disables hedging, requests then only fail over on errors.
+// SetHedgeDelay must not be called concurrently with requests.
+func (r *RelayClient) SetHedgeDelay(delay time.Duration) {
+	r.hedgeDelay = delay
+}
+
+func firstEndpoint(endpoints []string) string {
+	if len(endpoints) == 0 {
+		return ""
+	}
+	return endpoints[0]
+}
+
+// StatusError is the error of requests an endpoint answered with a non-2xx HTTP status and a body that is not a
+// JSON-RPC response, e.g. a 503 page from a proxy in front of the relay. The response body is kept in the
+// ResponseBytes of the SendBundleResponse.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return "unexpected HTTP status " + e.Status
+}
+
+type endpointAttempt struct {
+	endpoint      string
+	responseBytes []byte
+	timing        RequestTiming
+	err           error
+}
+
+// request sends the payload of a JSON-RPC method to endpoints with sendRequest and appends it to the journal of r
+func (r *RelayClient) request(ctx context.Context, method string, endpoints []string, payload []byte) (resp SendBundleResponse) {
+	start := time.Now()
+	resp = r.sendRequest(ctx, method, endpoints, payload)
+	if r.journal != nil {
+		r.journal.record(r, method, start, payload, resp)
+	}
+	return
+}
+
+// sendRequest sends the payload of a JSON-RPC method to endpoints in order once the rate limits of r allow it,
+// failing over to the next endpoint when one errors, including with a *StatusError, and, with a hedge delay, when
+// one takes too long. Requests still in flight once an endpoint answered are canceled. The response holds the total duration and the timing of
+// the endpoint that answered, or of the last one if all of them failed.
+func (r *RelayClient) sendRequest(ctx context.Context, method string, endpoints []string, payload []byte) (resp SendBundleResponse) {
+	if len(endpoints) == 0 {
+		return SendBundleResponse{
+			Error: errors.New("no endpoint for relay " + r.name),
+		}
+	}
+	if err := r.waitRateLimit(ctx, method); err != nil {
+		return SendBundleResponse{
+			Error: err,
+		}
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	attempts := make(chan endpointAttempt, len(endpoints))
+	next, inFlight := 0, 0
+	launch := func() {
+		endpoint := endpoints[next]
+		next++
+		inFlight++
+		go func() {
+			responseBytes, timing, err := r.fbRequest(ctx, endpoint, payload)
+			attempts <- endpointAttempt{endpoint: endpoint, responseBytes: responseBytes, timing: timing, err: err}
+		}()
+	}
+
+	var hedge *time.Timer
+	var hedgeC <-chan time.Time
+	armHedge := func() {
+		if hedge != nil {
+			hedge.Stop()
+		}
+		hedgeC = nil
+		if r.hedgeDelay > 0 && next < len(endpoints) {
+			hedge = time.NewTimer(r.hedgeDelay)
+			hedgeC = hedge.C
+		}
+	}
+	defer func() {
+		if hedge != nil {
+			hedge.Stop()
+		}
+	}()
+
+	launch()
+	armHedge()
+	var last endpointAttempt
+	failed := 0
+	for inFlight > 0 {
+		select {
+		case <-hedgeC:
+			launch()
+			armHedge()
+		case attempt := <-attempts:
+			inFlight--
+			if attempt.err == nil {
+				return SendBundleResponse{
+					ResponseBytes: attempt.responseBytes,
+					Duration:      time.Since(start),
+					Timing:        attempt.timing,
+					Endpoint:      attempt.endpoint,
+				}
+			}
+			last = attempt
+			failed++
+			if next < len(endpoints) && ctx.Err() == nil {
+				launch()
+				armHedge()
+			}
+		}
+	}
+
+	resp = SendBundleResponse{
+		ResponseBytes: last.responseBytes,
+		Duration:      time.Since(start),
+		Timing:        last.timing,
+		Endpoint:      last.endpoint,
+		Error:         last.err,
+	}
+	if failed > 1 {
+		resp.Error = fmt.Errorf("%d endpoints failed, last %s: %w", failed, last.endpoint, last.err)
+	}
+	return
+}
@@ -0,0 +1,130 @@
+package flashbots
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestRelayClient_request(t *testing.T) {
+	result := []byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`)
+	var slowRequests, fastRequests int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&slowRequests, 1)
+		_, _ = io.Copy(io.Discard, req.Body)
+		select {
+		case <-time.After(time.Second):
+		case <-req.Context().Done():
+		}
+		_, _ = w.Write(result)
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&fastRequests, 1)
+		_, _ = w.Write(result)
+	}))
+	defer fast.Close()
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`<html><body>503 Service Unavailable</body></html>`))
+	}))
+	defer unavailable.Close()
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"nonce too low"}}`))
+	}))
+	defer rejecting.Close()
+	down := "http://127.0.0.1:1"
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	tests := []struct {
+		name         string
+		endpoints    []string
+		hedgeDelay   time.Duration
+		wantEndpoint string
+		wantErr      string
+		maxDuration  time.Duration
+	}{
+		{
+			name:         "first endpoint serves",
+			endpoints:    []string{fast.URL, down},
+			wantEndpoint: fast.URL,
+		},
+		{
+			name:         "fails over on error",
+			endpoints:    []string{down, fast.URL},
+			wantEndpoint: fast.URL,
+		},
+		{
+			name:         "fails over on HTTP error status",
+			endpoints:    []string{unavailable.URL, fast.URL},
+			wantEndpoint: fast.URL,
+		},
+		{
+			name:         "HTTP error status",
+			endpoints:    []string{unavailable.URL},
+			wantEndpoint: unavailable.URL,
+			wantErr:      "unexpected HTTP status 503",
+		},
+		{
+			name:         "JSON-RPC error with HTTP error status",
+			endpoints:    []string{rejecting.URL, fast.URL},
+			wantEndpoint: rejecting.URL,
+		},
+		{
+			name:         "hedges slow endpoint",
+			endpoints:    []string{slow.URL, fast.URL},
+			hedgeDelay:   20 * time.Millisecond,
+			wantEndpoint: fast.URL,
+			maxDuration:  500 * time.Millisecond,
+		},
+		{
+			name:         "all endpoints fail",
+			endpoints:    []string{down, down + "/"},
+			wantEndpoint: down + "/",
+			wantErr:      "2 endpoints failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRelayClientWithEndpoints(pkey, "relay", tt.endpoints, nil)
+			if err != nil {
+				t.Fatalf("NewRelayClientWithEndpoints() error = %v", err)
+			}
+			r.SetHedgeDelay(tt.hedgeDelay)
+
+			resp := r.SendBundle(Bundle{})
+			if resp.Endpoint != tt.wantEndpoint {
+				t.Errorf("Endpoint = %v, want %v", resp.Endpoint, tt.wantEndpoint)
+			}
+			if tt.wantErr == "" && resp.Error != nil {
+				t.Errorf("Error = %v", resp.Error)
+			}
+			if tt.wantErr != "" && (resp.Error == nil || !strings.Contains(resp.Error.Error(), tt.wantErr)) {
+				t.Errorf("Error = %v, want %v", resp.Error, tt.wantErr)
+			}
+			if tt.maxDuration != 0 && resp.Duration > tt.maxDuration {
+				t.Errorf("Duration = %v, want at most %v", resp.Duration, tt.maxDuration)
+			}
+		})
+	}
+	if got := atomic.LoadInt32(&slowRequests); got != 1 {
+		t.Errorf("slow endpoint requests = %v, want 1", got)
+	}
+
+	r, _ := NewRelayClientWithEndpoints(pkey, "relay", []string{fast.URL}, []string{down, fast.URL})
+	resp := r.SimulateBundleContext(context.Background(), Bundle{}, nil)
+	if resp.Error != nil || resp.Endpoint != fast.URL {
+		t.Errorf("SimulateBundleContext() = %+v, want served by %v", resp, fast.URL)
+	}
+	if r.SimulationEndpoint() != down || len(r.SimulationEndpoints()) != 2 {
+		t.Errorf("SimulationEndpoints() = %v", r.SimulationEndpoints())
+	}
+}
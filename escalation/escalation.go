@@ -0,0 +1,322 @@
+// Package escalation resubmits a bundle for every new block until it is included, raising the builder payment each
+// block according to a schedule.
+package escalation
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/wphan/go-flashbots"
+	"github.com/wphan/go-flashbots/pricing"
+	"github.com/wphan/go-flashbots/txbuilder"
+)
+
+// ChainReader reads the state needed to tell if a bundle was included. *ethclient.Client satisfies ChainReader.
+type ChainReader interface {
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Schedule returns the payment of a round given the payment of the previous round, round 0 being the first
+// submission. previous is nil in round 0.
+type Schedule func(round int, previous *big.Int) *big.Int
+
+// Linear raises the payment by step every round
+func Linear(start, step *big.Int) Schedule {
+	return func(round int, previous *big.Int) *big.Int {
+		return new(big.Int).Add(start, new(big.Int).Mul(step, big.NewInt(int64(round))))
+	}
+}
+
+// Multiplicative multiplies the payment by factorBps basis points every round, e.g. 11000 raises it by 10%
+func Multiplicative(start *big.Int, factorBps uint64) Schedule {
+	return func(round int, previous *big.Int) *big.Int {
+		if previous == nil {
+			return new(big.Int).Set(start)
+		}
+		next := new(big.Int).Mul(previous, new(big.Int).SetUint64(factorBps))
+		return next.Div(next, big.NewInt(pricing.BasisPoints))
+	}
+}
+
+// Config describes the bundle to escalate
+type Config struct {
+	Template txbuilder.Template // Template is built for every new block
+	Payment  txbuilder.Payment  // Payment appended to the bundle, its amount is set by Schedule
+	Schedule Schedule
+
+	// Profit and MaxProfitShareBps cap the payment to MaxProfitShareBps basis points of Profit, nil Profit for no cap
+	Profit            *big.Int
+	MaxProfitShareBps uint64
+
+	// ExpiryBlock is the last block the bundle is sent for, 0 to escalate until included or invalidated
+	ExpiryBlock uint64
+
+	// ReplacementUUID identifies every version of the bundle, a random one is used if empty
+	ReplacementUUID string
+
+	// OnRound is called after every submission, may be nil
+	OnRound func(Round)
+}
+
+// Round is a single submission of the bundle
+type Round struct {
+	Round       int
+	BlockNumber uint64
+	Payment     *big.Int
+	Bundle      flashbots.Bundle
+	Responses   map[string]flashbots.SendBundleResponse
+}
+
+type StopReason int
+
+const (
+	StopError            StopReason = iota // StopError means Run failed with an error
+	StopIncluded                           // StopIncluded means a version of the bundle was included
+	StopNonceInvalidated                   // StopNonceInvalidated means a nonce of the bundle was used by another transaction
+	StopExpired                            // StopExpired means ExpiryBlock passed
+	StopCanceled                           // StopCanceled means the context was canceled or the head channel closed
+)
+
+func (s StopReason) String() string {
+	switch s {
+	case StopError:
+		return "error"
+	case StopIncluded:
+		return "included"
+	case StopNonceInvalidated:
+		return "nonce invalidated"
+	case StopExpired:
+		return "expired"
+	case StopCanceled:
+		return "canceled"
+	default:
+		return "unknown"
+	}
+}
+
+// Result is the outcome of an escalation
+type Result struct {
+	Reason        StopReason
+	Rounds        int
+	LastRound     *Round         // LastRound is the last submission, nil if there was none
+	IncludedBlock uint64         // IncludedBlock is the block the bundle was included in, if Reason is StopIncluded
+	IncludedTx    *types.Receipt // IncludedTx is the receipt of the first included transaction, if Reason is StopIncluded
+}
+
+type Escalator struct {
+	builder *txbuilder.Builder
+	relay   flashbots.Relay
+	chain   ChainReader
+}
+
+// NewEscalator creates a new bundle escalator
+// builder: builds and re-signs the bundle for every block
+// relay:   relays the bundle is sent to
+// chain:   used to detect inclusion and nonce invalidation
+func NewEscalator(builder *txbuilder.Builder, relay flashbots.Relay, chain ChainReader) (e *Escalator, retErr error) {
+	if builder == nil || relay == nil || chain == nil {
+		retErr = errors.New("must provide a builder, relay and chain")
+		return
+	}
+	e = &Escalator{
+		builder: builder,
+		relay:   relay,
+		chain:   chain,
+	}
+	return
+}
+
+// Run sends a version of the bundle for the block after every header received on heads until a version is included,
+// a nonce of the bundle is invalidated, ExpiryBlock passes, ctx is canceled, heads is closed or an error occurs. When
+// stopping for any reason but inclusion the last version is canceled with its ReplacementUUID.
+func (e *Escalator) Run(ctx context.Context, heads <-chan *types.Header, cfg Config) (result Result, retErr error) {
+	defer func() {
+		if retErr != nil {
+			result.Reason = StopError
+			e.cancel(result, cfg)
+		}
+	}()
+	if cfg.Schedule == nil {
+		retErr = errors.New("must provide a schedule")
+		return
+	}
+	if cfg.ReplacementUUID == "" {
+		cfg.ReplacementUUID, retErr = newUUID()
+		if retErr != nil {
+			return
+		}
+	}
+
+	var payment *big.Int
+	versions := make(map[txKey][]common.Hash)
+	for {
+		var head *types.Header
+		var ok bool
+		select {
+		case <-ctx.Done():
+		case head, ok = <-heads:
+		}
+		if head == nil || !ok {
+			result.Reason = StopCanceled
+			e.cancel(result, cfg)
+			return
+		}
+
+		if result.LastRound != nil {
+			reason, receipt, stop, err := e.status(ctx, head, result.LastRound.Bundle, versions)
+			if err != nil {
+				retErr = err
+				return
+			}
+			if stop {
+				result.Reason = reason
+				if receipt != nil {
+					result.IncludedTx = receipt
+					result.IncludedBlock = receipt.BlockNumber.Uint64()
+				}
+				e.cancel(result, cfg)
+				return
+			}
+		}
+
+		blockNumber := head.Number.Uint64() + 1
+		if cfg.ExpiryBlock != 0 && blockNumber > cfg.ExpiryBlock {
+			result.Reason = StopExpired
+			e.cancel(result, cfg)
+			return
+		}
+
+		payment = cfg.Schedule(result.Rounds, payment)
+		if cfg.Profit != nil {
+			maxPayment := new(big.Int).Mul(cfg.Profit, new(big.Int).SetUint64(cfg.MaxProfitShareBps))
+			maxPayment.Div(maxPayment, big.NewInt(pricing.BasisPoints))
+			if payment.Cmp(maxPayment) > 0 {
+				payment = maxPayment
+			}
+		}
+
+		b, err := e.builder.Build(ctx, cfg.Template, blockNumber)
+		if err != nil {
+			retErr = fmt.Errorf("failed to build bundle for block %d: %w", blockNumber, err)
+			return
+		}
+		_, err = e.builder.AppendPayment(ctx, &b, cfg.Payment, payment)
+		if err != nil {
+			retErr = fmt.Errorf("failed to append payment for block %d: %w", blockNumber, err)
+			return
+		}
+		b.ReplacementUUID = cfg.ReplacementUUID
+		if err := addVersions(versions, b); err != nil {
+			retErr = err
+			return
+		}
+
+		round := Round{
+			Round:       result.Rounds,
+			BlockNumber: blockNumber,
+			Payment:     payment,
+			Bundle:      b,
+			Responses:   e.relay.Send(ctx, b),
+		}
+		result.Rounds++
+		result.LastRound = &round
+		if cfg.OnRound != nil {
+			cfg.OnRound(round)
+		}
+	}
+}
+
+// txKey identifies a transaction of the bundle across rounds, which re-sign it with a new hash whenever its fees
+// change
+type txKey struct {
+	from  common.Address
+	nonce uint64
+}
+
+// addVersions adds the hash of every transaction of b to the versions sent of its sender and nonce
+func addVersions(versions map[txKey][]common.Hash, b flashbots.Bundle) error {
+	if len(b.Transactions) == 0 {
+		return nil
+	}
+	signer := types.LatestSignerForChainID(b.Transactions[0].ChainId())
+	for _, tx := range b.Transactions {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return fmt.Errorf("failed to get sender of tx %s: %w", tx.Hash().Hex(), err)
+		}
+		key := txKey{from: from, nonce: tx.Nonce()}
+		versions[key] = append(versions[key], tx.Hash())
+	}
+	return nil
+}
+
+// status checks whether the nonce of any sender in b was used at head, and if so, whether any version of the
+// transaction sent so far used it
+func (e *Escalator) status(ctx context.Context, head *types.Header, b flashbots.Bundle, versions map[txKey][]common.Hash) (reason StopReason, receipt *types.Receipt, stop bool, retErr error) {
+	signer := types.LatestSignerForChainID(b.Transactions[0].ChainId())
+	checked := make(map[common.Address]bool)
+	for _, tx := range b.Transactions {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			retErr = fmt.Errorf("failed to get sender of tx %s: %w", tx.Hash().Hex(), err)
+			return
+		}
+		if checked[from] {
+			continue
+		}
+		checked[from] = true
+
+		nonce, err := e.chain.NonceAt(ctx, from, head.Number)
+		if err != nil {
+			retErr = fmt.Errorf("failed to get nonce of %s: %w", from.Hex(), err)
+			return
+		}
+		if nonce <= tx.Nonce() {
+			continue
+		}
+
+		for _, hash := range versions[txKey{from: from, nonce: tx.Nonce()}] {
+			receipt, err = e.chain.TransactionReceipt(ctx, hash)
+			if err != nil && !errors.Is(err, ethereum.NotFound) {
+				receipt = nil
+				retErr = fmt.Errorf("failed to get receipt of tx %s: %w", hash.Hex(), err)
+				return
+			}
+			if err == nil && receipt != nil {
+				stop = true
+				reason = StopIncluded
+				return
+			}
+		}
+		receipt = nil
+		stop = true
+		reason = StopNonceInvalidated
+		return
+	}
+	return
+}
+
+func (e *Escalator) cancel(result Result, cfg Config) {
+	if result.LastRound == nil || result.Reason == StopIncluded {
+		return
+	}
+	e.relay.Cancel(context.Background(), cfg.ReplacementUUID)
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", fmt.Errorf("failed to generate replacement uuid: %w", err)
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16]), nil
+}
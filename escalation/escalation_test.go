@@ -0,0 +1,252 @@
+package escalation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/wphan/go-flashbots"
+	"github.com/wphan/go-flashbots/account"
+	"github.com/wphan/go-flashbots/txbuilder"
+)
+
+// testChain includes the transaction with nonce includedNonce at includedBlock
+type testChain struct {
+	includedBlock uint64
+	includedTx    common.Hash
+	nonce         uint64
+	receiptErr    error // receiptErr fails every receipt lookup if set
+}
+
+func (c *testChain) NonceAt(_ context.Context, _ common.Address, blockNumber *big.Int) (uint64, error) {
+	if c.includedBlock != 0 && blockNumber.Uint64() >= c.includedBlock {
+		return c.nonce + 1, nil
+	}
+	return c.nonce, nil
+}
+
+func (c *testChain) TransactionReceipt(_ context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if c.receiptErr != nil {
+		return nil, c.receiptErr
+	}
+	if txHash == c.includedTx {
+		return &types.Receipt{TxHash: txHash, BlockNumber: new(big.Int).SetUint64(c.includedBlock)}, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+type testRelay struct {
+	mu      sync.Mutex
+	methods []string
+	uuids   []string
+}
+
+func (r *testRelay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	var payload struct {
+		Method string                   `json:"method"`
+		Params []map[string]interface{} `json:"params"`
+	}
+	_ = json.Unmarshal(body, &payload)
+	r.mu.Lock()
+	r.methods = append(r.methods, payload.Method)
+	r.uuids = append(r.uuids, payload.Params[0]["replacementUuid"].(string))
+	r.mu.Unlock()
+	_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+}
+
+func newTestEscalator(t *testing.T, chain ChainReader) (*Escalator, *testRelay, common.Address) {
+	pkey, pubAddr, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	relay := &testRelay{}
+	server := httptest.NewServer(relay)
+	t.Cleanup(server.Close)
+
+	relayClient, _ := flashbots.NewRelayClient(pkey, "relay", server.URL, "")
+	batch, _ := flashbots.NewBatchRelayClientFromRelayClients(relayClient)
+	builder, err := txbuilder.NewBuilder(big.NewInt(1), pkey, txbuilder.NonceMap{pubAddr: 5}, txbuilder.Fees{
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: big.NewInt(50 * params.GWei),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewEscalator(builder, batch, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, relay, pubAddr
+}
+
+func TestEscalator_Run(t *testing.T) {
+	chain := &testChain{nonce: 5}
+	e, relay, pubAddr := newTestEscalator(t, chain)
+
+	heads := make(chan *types.Header, 4)
+	for n := int64(100); n < 104; n++ {
+		heads <- &types.Header{Number: big.NewInt(n)}
+	}
+
+	rounds := make([]Round, 0)
+	cfg := Config{
+		Template:          txbuilder.Template{Requests: []txbuilder.TxRequest{{To: &pubAddr, Gas: 21000}}},
+		Payment:           txbuilder.Payment{To: common.HexToAddress("0xdafea492d9c6733ae3d56b7ed1adb60692c98bc5"), Gas: 21000},
+		Schedule:          Multiplicative(big.NewInt(1000), 15000),
+		Profit:            big.NewInt(2500),
+		MaxProfitShareBps: 9000,
+		ReplacementUUID:   "a6d9b5b2-7f6b-4f57-9c0e-2a2f2b6f6c1e",
+		OnRound: func(r Round) {
+			rounds = append(rounds, r)
+			// the version sent for block 103 is included
+			if r.BlockNumber == 103 {
+				chain.includedBlock = 103
+				chain.includedTx = r.Bundle.Transactions[0].Hash()
+			}
+		},
+	}
+	result, err := e.Run(context.Background(), heads, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Reason != StopIncluded || result.IncludedBlock != 103 {
+		t.Errorf("Run() = %v at %d, want included at 103", result.Reason, result.IncludedBlock)
+	}
+	wantPayments := []int64{1000, 1500, 2250}
+	if len(rounds) != len(wantPayments) {
+		t.Fatalf("Run() rounds = %v, want %v", len(rounds), len(wantPayments))
+	}
+	for i, r := range rounds {
+		if r.Payment.Cmp(big.NewInt(wantPayments[i])) != 0 {
+			t.Errorf("round %d payment = %v, want %v", i, r.Payment, wantPayments[i])
+		}
+		if r.BlockNumber != uint64(101+i) || r.Bundle.ReplacementUUID != cfg.ReplacementUUID {
+			t.Errorf("round %d block %d uuid %s", i, r.BlockNumber, r.Bundle.ReplacementUUID)
+		}
+	}
+	if rounds[0].Bundle.Transactions[1].Hash() == rounds[1].Bundle.Transactions[1].Hash() {
+		t.Errorf("payment was not re-signed between rounds")
+	}
+	for _, m := range relay.methods {
+		if m != "eth_sendBundle" {
+			t.Errorf("relay received %s, want only eth_sendBundle once included", m)
+		}
+	}
+}
+
+func TestEscalator_Run_Expired(t *testing.T) {
+	e, relay, pubAddr := newTestEscalator(t, &testChain{nonce: 5})
+
+	heads := make(chan *types.Header, 3)
+	for n := int64(100); n < 103; n++ {
+		heads <- &types.Header{Number: big.NewInt(n)}
+	}
+	result, err := e.Run(context.Background(), heads, Config{
+		Template:    txbuilder.Template{Requests: []txbuilder.TxRequest{{To: &pubAddr, Gas: 21000}}},
+		Payment:     txbuilder.Payment{To: pubAddr, Gas: 21000},
+		Schedule:    Linear(big.NewInt(1000), big.NewInt(100)),
+		ExpiryBlock: 102,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reason != StopExpired || result.Rounds != 2 {
+		t.Errorf("Run() = %v after %d rounds, want expired after 2", result.Reason, result.Rounds)
+	}
+	if result.LastRound.Payment.Cmp(big.NewInt(1100)) != 0 {
+		t.Errorf("Run() last payment = %v, want 1100", result.LastRound.Payment)
+	}
+	if last := relay.methods[len(relay.methods)-1]; last != "eth_cancelBundle" || relay.uuids[len(relay.uuids)-1] != result.LastRound.Bundle.ReplacementUUID {
+		t.Errorf("Run() did not cancel the last version, last method %s", last)
+	}
+}
+
+func TestEscalator_Run_NonceInvalidated(t *testing.T) {
+	chain := &testChain{nonce: 5}
+	e, _, pubAddr := newTestEscalator(t, chain)
+
+	heads := make(chan *types.Header, 2)
+	heads <- &types.Header{Number: big.NewInt(100)}
+	heads <- &types.Header{Number: big.NewInt(101)}
+	chain.includedBlock = 101 // another transaction used nonce 5
+
+	result, err := e.Run(context.Background(), heads, Config{
+		Template: txbuilder.Template{Requests: []txbuilder.TxRequest{{To: &pubAddr, Gas: 21000}}},
+		Payment:  txbuilder.Payment{To: pubAddr, Gas: 21000},
+		Schedule: Linear(big.NewInt(1000), big.NewInt(100)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reason != StopNonceInvalidated {
+		t.Errorf("Run() = %v, want %v", result.Reason, StopNonceInvalidated)
+	}
+}
+
+func TestEscalator_Run_IncludedEarlierVersion(t *testing.T) {
+	chain := &testChain{nonce: 5}
+	e, _, pubAddr := newTestEscalator(t, chain)
+
+	heads := make(chan *types.Header, 3)
+	for n := int64(100); n < 103; n++ {
+		heads <- &types.Header{Number: big.NewInt(n)}
+	}
+
+	var rounds []Round
+	result, err := e.Run(context.Background(), heads, Config{
+		Template: txbuilder.Template{Requests: []txbuilder.TxRequest{{To: &pubAddr, Gas: 21000}}},
+		Payment:  txbuilder.Payment{To: pubAddr, Gas: 21000},
+		Schedule: Linear(big.NewInt(1000), big.NewInt(100)),
+		OnRound: func(r Round) {
+			rounds = append(rounds, r)
+			if r.Round == 0 {
+				// the next round re-signs the transaction with new fees
+				_ = e.builder.SetFees(txbuilder.Fees{GasTipCap: big.NewInt(2 * params.GWei), GasFeeCap: big.NewInt(60 * params.GWei)})
+				return
+			}
+			// the version sent in round 0 is included
+			chain.includedBlock = 102
+			chain.includedTx = rounds[0].Bundle.Transactions[0].Hash()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rounds[0].Bundle.Transactions[0].Hash() == rounds[1].Bundle.Transactions[0].Hash() {
+		t.Fatal("transaction was not re-signed after the fee change")
+	}
+	if result.Reason != StopIncluded || result.IncludedTx.TxHash != chain.includedTx {
+		t.Errorf("Run() = %v, want included with the round 0 version", result.Reason)
+	}
+}
+
+func TestEscalator_Run_ReceiptError(t *testing.T) {
+	chain := &testChain{nonce: 5, receiptErr: errors.New("connection reset")}
+	e, relay, pubAddr := newTestEscalator(t, chain)
+
+	heads := make(chan *types.Header, 2)
+	heads <- &types.Header{Number: big.NewInt(100)}
+	heads <- &types.Header{Number: big.NewInt(101)}
+	chain.includedBlock = 101
+
+	result, err := e.Run(context.Background(), heads, Config{
+		Template: txbuilder.Template{Requests: []txbuilder.TxRequest{{To: &pubAddr, Gas: 21000}}},
+		Payment:  txbuilder.Payment{To: pubAddr, Gas: 21000},
+		Schedule: Linear(big.NewInt(1000), big.NewInt(100)),
+	})
+	if !errors.Is(err, chain.receiptErr) || result.Reason != StopError {
+		t.Errorf("Run() = %v, %v, want the receipt error", result.Reason, err)
+	}
+	if last := relay.methods[len(relay.methods)-1]; last != "eth_cancelBundle" {
+		t.Errorf("Run() did not cancel the last version after an error, last method %s", last)
+	}
+}
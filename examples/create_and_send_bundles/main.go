@@ -1,12 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/wphan/go-flashbots"
 	"github.com/wphan/go-flashbots/account"
+	"github.com/wphan/go-flashbots/txbuilder"
 )
 
 func main() {
@@ -17,34 +18,29 @@ func main() {
 		panic(err)
 	}
 
-	// create some signed transactions
-	tx, err := types.SignNewTx(pkey, types.NewEIP2930Signer(big.NewInt(1)), &types.AccessListTx{
-		ChainID:  big.NewInt(1),
-		Nonce:    0,
-		GasPrice: nil,
-		Gas:      5000000,
-		To:       &pubAddr,
-		Value:    big.NewInt(0),
-		Data:     nil,
-	})
-	tx2, err := types.SignNewTx(pkey, types.NewEIP2930Signer(big.NewInt(1)), &types.AccessListTx{
-		ChainID:  big.NewInt(1),
-		Nonce:    1,
-		GasPrice: nil,
-		Gas:      5000000,
-		To:       &pubAddr,
-		Value:    big.NewInt(0),
-		Data:     nil,
+	// create a transaction builder, use an *ethclient.Client as the nonce source to get pending nonces from a node
+	builder, err := txbuilder.NewBuilder(big.NewInt(1), pkey, txbuilder.NonceMap{pubAddr: 0}, txbuilder.Fees{
+		GasTipCap: big.NewInt(2000000000),
+		GasFeeCap: big.NewInt(50000000000),
 	})
+	if err != nil {
+		panic(err)
+	}
 
-	// create the bundle
-	b, err := flashbots.NewBundle([]*types.Transaction{tx, tx2}, 12639480, 0, nil, nil, nil)
+	// create the bundle, nonces are assigned sequentially
+	template := txbuilder.Template{
+		Requests: []txbuilder.TxRequest{
+			{To: &pubAddr, Gas: 21000, Value: big.NewInt(0)},
+			{To: &pubAddr, Gas: 21000, Value: big.NewInt(0)},
+		},
+	}
+	b, err := builder.Build(context.Background(), template, 12639480)
 	if err != nil {
 		panic(err)
 	}
 
 	// simulate bundle, send bundle is similar
-	resp, duration, err := r.SimulateBundle(b)
+	resp, duration, err := r.SimulateBundle(b, nil)
 	// took: 264.911015ms
 	fmt.Printf("took: %+v\n", duration)
 	// response: map[id:1 jsonrpc:2.0 result:map[bundleGasPrice:0 bundleHash:0xacaf0c77e88712d83e26ffad84b7f2ed5690451d5fd92fa6adbf7fd7b53530be coinbaseDiff:0 ethSentToCoinbase:0 gasFees:0 results:[map[coinbaseDiff:0 ethSentToCoinbase:0 fromAddress:0xb73C1b61eECdD422A095E619d121C3162fd9fD51 gasFees:0 gasPrice:0 gasUsed:21000 toAddress:0xb73C1b61eECdD422A095E619d121C3162fd9fD51 txHash:0x442bc407a878ec5144f3d9d57f043b416f750fb69344c85d99dbe516c2735931 value:0x] map[coinbaseDiff:0 ethSentToCoinbase:0 fromAddress:0xb73C1b61eECdD422A095E619d121C3162fd9fD51 gasFees:0 gasPrice:0 gasUsed:21000 toAddress:0xb73C1b61eECdD422A095E619d121C3162fd9fD51 txHash:0x62790e732190d74b8fb2c302b5f90a9bcb124abef2b945bc570cf977f4414e93 value:0x]] stateBlockNumber:1.2639768e+07 totalGasUsed:42000]]
@@ -53,4 +49,10 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+
+	// rebuilding the template for the next block re-signs the transactions
+	b, err = builder.Build(context.Background(), template, 12639481)
+	if err != nil {
+		panic(err)
+	}
 }
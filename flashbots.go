@@ -2,13 +2,17 @@ package flashbots
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
+	"net/http/httptrace"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -27,6 +31,10 @@ type Bundle struct {
 
 	// RevertingTxHashes contain list of transaction hashes that are "allowed to revert" - bundle will still land on chain if these transactions revert
 	RevertingTxHashes []string `json:"revertingTxHashes,omitempty"`
+
+	// ReplacementUUID identifies the bundle for replacement and cancellation - sending a bundle with the same
+	// ReplacementUUID replaces the previous one, CancelBundle cancels it
+	ReplacementUUID string `json:"replacementUuid,omitempty"`
 }
 
 // NewBundle creates a new bundle.
@@ -66,9 +74,16 @@ func NewBundle(transactions []*types.Transaction, blockNumber, stateBlockNumber
 	return
 }
 
-// AddTransaction to existing bundle
-func (b *Bundle) AddTransaction(tx *types.Transaction) {
+// AddTransaction to existing bundle, keeping TxsByteString in sync with Transactions
+func (b *Bundle) AddTransaction(tx *types.Transaction) (retErr error) {
+	txBytes, err := tx.MarshalBinary()
+	if err != nil {
+		retErr = fmt.Errorf("failed tx.MarshalBinary() for tx: %s\nerrors: %w", tx.Hash().Hex(), err)
+		return
+	}
 	b.Transactions = append(b.Transactions, tx)
+	b.TxsByteString = append(b.TxsByteString, "0x"+common.Bytes2Hex(txBytes))
+	return
 }
 
 type rpcPaylod struct {
@@ -82,11 +97,32 @@ type RelayClient struct {
 	name                 string            // name used to identify this RelayClient
 	signingPrivateKey    *ecdsa.PrivateKey // signingPrivateKey signs bundles for flashbots
 	signingPublicAddress common.Address    // signingPublicAddress is the public Ethereum address of signingPrivateKey
-	mainEndpoint         string            // mainEndpoint of the relay server, bundles are sent to this server
+	mainEndpoints        []string          // mainEndpoints of the relay server in order of preference, bundles are sent to these servers
 
-	// simulationEndpoint is used for simulating bundles
+	// simulationEndpoints are used for simulating bundles
 	// this is useful if you run a local version of mev-geth and don't want to wait for the slow public relays to respond
-	simulationEndpoint string
+	simulationEndpoints []string
+
+	// hedgeDelay is how long a request waits for an endpoint before it is also sent to the next one, 0 to only fail over on errors
+	hedgeDelay time.Duration
+
+	// simulationBackend simulates bundles instead of simulationEndpoints if set
+	simulationBackend SimulationBackend
+
+	// rateLimits limit requests by JSON-RPC method, "" limits all methods
+	rateLimits map[string]*rateLimiter
+
+	// simulationCache caches simulations if set
+	simulationCache *SimulationCache
+
+	// journal records every request if set
+	journal *Journal
+
+	// stats hold the statistics of the bundles sent, see Stats
+	stats *clientStats
+
+	// httpClient sends the requests, http.DefaultClient if nil
+	httpClient *http.Client
 }
 
 // NewRelayClient creates a new relay client
@@ -94,25 +130,23 @@ type RelayClient struct {
 // mainEndpoint:        the relay server endpoint used for sending bundles
 // simulationEndpoint:  the relay server endpoint used for bundle simulation
 func NewRelayClient(signingPrivateKey *ecdsa.PrivateKey, name, mainEndpoint, simulationEndpoint string) (r *RelayClient, retErr error) {
-	if signingPrivateKey == nil {
-		retErr = errors.New("must provide a signingPrivateKey")
-		return
-	}
-	r = &RelayClient{
-		name:                 name,
-		signingPrivateKey:    signingPrivateKey,
-		signingPublicAddress: crypto.PubkeyToAddress(signingPrivateKey.PublicKey),
-		mainEndpoint:         mainEndpoint,
-		simulationEndpoint:   simulationEndpoint,
+	simulationEndpoints := make([]string, 0, 1)
+	if simulationEndpoint != "" {
+		simulationEndpoints = append(simulationEndpoints, simulationEndpoint)
 	}
+	return NewRelayClientWithEndpoints(signingPrivateKey, name, []string{mainEndpoint}, simulationEndpoints)
+}
 
-	return
+// SetHTTPClient makes r send its requests with client, e.g. to use a custom http.RoundTripper, nil to use
+// http.DefaultClient. SetHTTPClient must not be called concurrently with requests.
+func (r *RelayClient) SetHTTPClient(client *http.Client) {
+	r.httpClient = client
 }
 
 func (r RelayClient) Name() string                   { return r.name }
 func (r RelayClient) SigningAddress() common.Address { return r.signingPublicAddress }
-func (r RelayClient) MainEndpoint() string           { return r.mainEndpoint }
-func (r RelayClient) SimulationEndpoint() string     { return r.simulationEndpoint }
+func (r RelayClient) MainEndpoint() string           { return firstEndpoint(r.mainEndpoints) }
+func (r RelayClient) SimulationEndpoint() string     { return firstEndpoint(r.simulationEndpoints) }
 
 func (r *RelayClient) prepareBundlePayload(b Bundle, method string) (payloadBytes []byte, retErr error) {
 
@@ -147,6 +181,24 @@ func (r *RelayClient) prepareBundleStatsPayload(bundleHash, blockNumber, method
 	return
 }
 
+// prepareCancelBundlePayload prepares a payload cancelling the bundles sent with replacementUUID
+func (r *RelayClient) prepareCancelBundlePayload(replacementUUID string) (payloadBytes []byte, retErr error) {
+
+	payload := rpcPaylod{
+		JsonRPC: "2.0",
+		Method:  "eth_cancelBundle",
+		Params: []map[string]string{
+			{
+				"replacementUuid": replacementUUID,
+			},
+		},
+		ID: 1,
+	}
+
+	payloadBytes, retErr = json.Marshal(payload)
+	return
+}
+
 func (r *RelayClient) signPayload(payload []byte) (signature string, retErr error) {
 	hashedBody := crypto.Keccak256Hash(payload).Hex()
 	payloadHash := crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n" + strconv.Itoa(len(hashedBody)) + hashedBody))
@@ -157,33 +209,45 @@ func (r *RelayClient) signPayload(payload []byte) (signature string, retErr erro
 	return hexutil.Encode(signatureBytes), nil
 }
 
-func (r *RelayClient) fbRequest(endpoint string, payload []byte) (responseBytes []byte, duration time.Duration, retErr error) {
+func (r *RelayClient) fbRequest(ctx context.Context, endpoint string, payload []byte) (responseBytes []byte, timing RequestTiming, retErr error) {
 	signature, err := r.signPayload(payload)
 	if err != nil {
 		retErr = err
 		return
 	}
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(payload))
+	trace := newTimingTrace()
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace.clientTrace()), http.MethodPost, endpoint, bytes.NewBuffer(payload))
 	if err != nil {
 		retErr = err
 		return
 	}
 	req.Header.Add("X-Flashbots-Signature", r.signingPublicAddress.Hex()+":"+signature)
 	req.Header.Set("Content-Type", "application/json")
-	start := time.Now()
-	resp, err := http.DefaultClient.Do(req)
-	duration = time.Since(start)
+	client := r.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
 	if err != nil {
+		timing = trace.finish()
 		retErr = err
 		return
 	}
 	responseBytes, err = ioutil.ReadAll(resp.Body)
 	defer resp.Body.Close()
+	timing = trace.finish()
 	if err != nil {
 		retErr = err
 		return
 	}
+	// relays answer some JSON-RPC errors, e.g. an invalid bundle, with a 4xx status, those reached the relay
+	if (resp.StatusCode < 200 || resp.StatusCode > 299) && !isRPCResponse(responseBytes) {
+		retErr = &StatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+		}
+	}
 
 	return
 }
@@ -200,11 +264,18 @@ func unmarshalBodyBytesToGenericMap(bytes []byte) (response map[string]interface
 type SendBundleResponse struct {
 	ResponseBytes []byte
 	Duration      time.Duration
+	Timing        RequestTiming // Timing breaks down the request to Endpoint, zero if the request was never sent
+	Endpoint      string        // Endpoint served the request, or was the last one tried if all endpoints failed
 	Error         error
+	DryRun        bool // DryRun is true if the request was not sent because of a dry run or shadow mode
 }
 
 // SendBundle sends a Bundle on RelayClient.
 func (r *RelayClient) SendBundle(b Bundle) (resp SendBundleResponse) {
+	return r.sendBundle(context.Background(), b)
+}
+
+func (r *RelayClient) sendBundle(ctx context.Context, b Bundle) (resp SendBundleResponse) {
 	payload, err := r.prepareBundlePayload(b, "eth_sendBundle")
 	if err != nil {
 		return SendBundleResponse{
@@ -212,29 +283,98 @@ func (r *RelayClient) SendBundle(b Bundle) (resp SendBundleResponse) {
 		}
 	}
 
-	responseBytes, duration, err := r.fbRequest(r.mainEndpoint, payload)
+	resp = r.request(ctx, "eth_sendBundle", r.mainEndpoints, payload)
+	r.stats.record(resp)
+	return
+}
 
-	return SendBundleResponse{
-		ResponseBytes: responseBytes,
-		Duration:      duration,
-		Error:         err,
+// SimulationOptions overrides the block context eth_callBundle simulates against. nil fields are left for the
+// simulation server to fill in from the state block.
+type SimulationOptions struct {
+	Coinbase   *common.Address // Coinbase of the simulated block, use to simulate against a specific builder
+	Timestamp  *uint64         // Timestamp of the simulated block
+	GasLimit   *uint64         // GasLimit of the simulated block
+	Difficulty *big.Int        // Difficulty of the simulated block
+	BaseFee    *big.Int        // BaseFee of the simulated block, use to simulate against a predicted next block base fee
+}
+
+// callBundleArgs are the params of an eth_callBundle request
+type callBundleArgs struct {
+	Bundle
+	Coinbase   string   `json:"coinbase,omitempty"`
+	Timestamp  *uint64  `json:"timestamp,omitempty"`
+	GasLimit   *uint64  `json:"gasLimit,omitempty"`
+	Difficulty *big.Int `json:"difficulty,omitempty"`
+	BaseFee    *big.Int `json:"baseFee,omitempty"`
+}
+
+func newCallBundleArgs(b Bundle, opts *SimulationOptions) (args callBundleArgs) {
+	args = callBundleArgs{Bundle: b}
+	if opts != nil {
+		if opts.Coinbase != nil {
+			args.Coinbase = opts.Coinbase.Hex()
+		}
+		args.Timestamp = opts.Timestamp
+		args.GasLimit = opts.GasLimit
+		args.Difficulty = opts.Difficulty
+		args.BaseFee = opts.BaseFee
 	}
+	return
 }
 
-func (r *RelayClient) SimulateBundle(b Bundle) (responseBytes []byte, duration time.Duration, retErr error) {
-	if r.simulationEndpoint == "" {
-		retErr = errors.New("no simulation endpoint for relay " + r.name)
-		return
+func (r *RelayClient) prepareCallBundlePayload(b Bundle, opts *SimulationOptions) (payloadBytes []byte, retErr error) {
+	payload := rpcPaylod{
+		JsonRPC: "2.0",
+		Method:  "eth_callBundle",
+		Params:  []callBundleArgs{newCallBundleArgs(b, opts)},
+		ID:      1,
 	}
+
+	payloadBytes, retErr = json.Marshal(payload)
+	return
+}
+
+// SimulateBundle simulates a Bundle with eth_callBundle on the simulation endpoint.
+// opts: block context overrides for the simulation, nil to simulate on top of the state block as is
+func (r *RelayClient) SimulateBundle(b Bundle, opts *SimulationOptions) (responseBytes []byte, duration time.Duration, retErr error) {
+	resp := r.simulateBundle(context.Background(), b, opts)
+	return resp.ResponseBytes, resp.Duration, resp.Error
+}
+
+// SimulateBundleContext is SimulateBundle with a context, its response holds the timing breakdown of the request
+// and the simulation endpoint that served it
+func (r *RelayClient) SimulateBundleContext(ctx context.Context, b Bundle, opts *SimulationOptions) (resp SendBundleResponse) {
+	return r.simulateBundle(ctx, b, opts)
+}
+
+func (r *RelayClient) simulateBundle(ctx context.Context, b Bundle, opts *SimulationOptions) (resp SendBundleResponse) {
 	if b.StateBlockNumber == "" || b.StateBlockNumber == "0x0" {
 		b.StateBlockNumber = "latest"
 	}
-	payload, err := r.prepareBundlePayload(b, "eth_callBundle")
+	if r.simulationCache != nil {
+		return r.simulationCache.simulate(ctx, b, opts, func(ctx context.Context) SendBundleResponse {
+			return r.simulateUncached(ctx, b, opts)
+		})
+	}
+	return r.simulateUncached(ctx, b, opts)
+}
+
+func (r *RelayClient) simulateUncached(ctx context.Context, b Bundle, opts *SimulationOptions) (resp SendBundleResponse) {
+	if r.simulationBackend != nil {
+		return r.simulationBackend.SimulateBundle(ctx, b, opts)
+	}
+	if len(r.simulationEndpoints) == 0 {
+		return SendBundleResponse{
+			Error: errors.New("no simulation endpoint for relay " + r.name),
+		}
+	}
+	payload, err := r.prepareCallBundlePayload(b, opts)
 	if err != nil {
-		retErr = err
-		return
+		return SendBundleResponse{
+			Error: err,
+		}
 	}
-	return r.fbRequest(r.simulationEndpoint, payload)
+	return r.request(ctx, "eth_callBundle", r.simulationEndpoints, payload)
 }
 
 type BundleStats struct {
@@ -250,48 +390,88 @@ type BundleStats struct {
 	} `json:"result"`
 }
 
+// CancelBundle cancels the bundles sent on RelayClient with replacementUUID. The signing address must be the same as
+// the one who submitted the bundles
+func (r *RelayClient) CancelBundle(replacementUUID string) (resp SendBundleResponse) {
+	return r.cancelBundle(context.Background(), replacementUUID)
+}
+
+func (r *RelayClient) cancelBundle(ctx context.Context, replacementUUID string) (resp SendBundleResponse) {
+	if replacementUUID == "" {
+		return SendBundleResponse{
+			Error: errors.New("must provide a replacementUUID"),
+		}
+	}
+	payload, err := r.prepareCancelBundlePayload(replacementUUID)
+	if err != nil {
+		return SendBundleResponse{
+			Error: err,
+		}
+	}
+
+	return r.request(ctx, "eth_cancelBundle", r.mainEndpoints, payload)
+}
+
 // GetBundleStats queries flashbots_getBundleStats for stats on a single bundle. BundleHash and blockNumber must be a hexadecimal strings
 func (r *RelayClient) GetBundleStats(bundleHash, blockNumber string) (bundleStats BundleStats, duration time.Duration, retErr error) {
+	bundleStats, resp, retErr := r.GetBundleStatsContext(context.Background(), bundleHash, blockNumber)
+	return bundleStats, resp.Duration, retErr
+}
+
+// GetBundleStatsContext is GetBundleStats with a context, resp holds the timing breakdown of the request and the
+// endpoint that served it
+func (r *RelayClient) GetBundleStatsContext(ctx context.Context, bundleHash, blockNumber string) (bundleStats BundleStats, resp SendBundleResponse, retErr error) {
 	payload, err := r.prepareBundleStatsPayload(bundleHash, blockNumber, "flashbots_getBundleStats")
 	if err != nil {
 		retErr = err
 		return
 	}
 
-	var bodyBytes []byte
-	bodyBytes, duration, err = r.fbRequest(r.MainEndpoint(), payload)
-	if err != nil {
-		retErr = fmt.Errorf("failed to make fbRequest: %w", err)
+	resp = r.request(ctx, "flashbots_getBundleStats", r.mainEndpoints, payload)
+	if resp.Error != nil {
+		retErr = fmt.Errorf("failed to make fbRequest: %w", resp.Error)
 		return
 	}
 
-	err = json.Unmarshal(bodyBytes, &bundleStats)
+	err = json.Unmarshal(resp.ResponseBytes, &bundleStats)
 	if err != nil {
-		retErr = fmt.Errorf("failed to unmarshal into BundleStats: %s\nerror: %w", string(bodyBytes), err)
+		retErr = fmt.Errorf("failed to unmarshal into BundleStats: %s\nerror: %w", string(resp.ResponseBytes), err)
 		return
 	}
 
 	return
 }
 
+// ExtractGasUsedFromBundleResponse returns the total gas used from the response bytes of an eth_callBundle request,
+// 0 if the response has no result.
+//
+// Deprecated: use ParseGasUsed, which reports why the response could not be parsed.
 func ExtractGasUsedFromBundleResponse(bytes []byte) (gasUsed float64) {
-	resp, err := unmarshalBodyBytesToGenericMap(bytes)
+	g, err := ParseGasUsed(bytes)
 	if err != nil {
-		panic(fmt.Errorf("body bytes: %s, %w", string(bytes), err))
+		return
 	}
-	gasUsed = resp["result"].(map[string]interface{})["totalGasUsed"].(float64)
+	gasUsed = float64(g)
 	return
 }
 
+// ExtractBundleHashFromBundleResponse returns the bundle hash from the response bytes of an eth_sendBundle or
+// eth_callBundle request, "" if the response has no result.
+//
+// Deprecated: use ParseBundleHash, which reports why the response could not be parsed.
 func ExtractBundleHashFromBundleResponse(bytes []byte) (bundleHash string) {
-	resp, err := unmarshalBodyBytesToGenericMap(bytes)
+	h, err := ParseBundleHash(bytes)
 	if err != nil {
-		panic(fmt.Errorf("body bytes: %s, %w", string(bytes), err))
+		return
 	}
-	bundleHash = resp["result"].(map[string]interface{})["bundleHash"].(string)
+	bundleHash = h.Hex()
 	return
 }
 
+// ExtractExecutionErrorFromSendBundleResponse returns an error for every transaction that failed in the response
+// bytes of an eth_callBundle request, with its raw revert data.
+//
+// Deprecated: use ParseCallBundleResponse and CallBundleResult.ExecutionErrors, which decode revert reasons.
 func ExtractExecutionErrorFromSendBundleResponse(bytes []byte) (errs []error) {
 	errs = make([]error, 0)
 
@@ -316,7 +496,7 @@ func ExtractExecutionErrorFromSendBundleResponse(bytes []byte) (errs []error) {
 		}
 		errs = append(
 			errs,
-			errors.New(txResultsI["message"].(string)))
+			errors.New("no result in response: "+string(bytes)))
 		return
 	}
 	txResults, ok := txResultsI["results"].([]interface{})
@@ -324,13 +504,24 @@ func ExtractExecutionErrorFromSendBundleResponse(bytes []byte) (errs []error) {
 		return
 	}
 	for _, txI := range txResults {
-		tx := txI.(map[string]interface{})
+		tx, ok := txI.(map[string]interface{})
+		if !ok {
+			continue
+		}
 		errString, errOk := tx["error"]
 		revertString, revertOk := tx["revert"]
-		if errOk || revertOk {
+		if errOk && revertOk {
 			errs = append(
 				errs,
 				fmt.Errorf("err: %s, revertString: %s", errString, revertString))
+		} else if errOk {
+			errs = append(
+				errs,
+				fmt.Errorf("err: %s", errString))
+		} else if revertOk {
+			errs = append(
+				errs,
+				fmt.Errorf("revertString: %s", revertString))
 		}
 	}
 
@@ -338,7 +529,22 @@ func ExtractExecutionErrorFromSendBundleResponse(bytes []byte) (errs []error) {
 }
 
 type BatchRelayClient struct {
+	name         string
 	relayClients []*RelayClient
+	relays       []Relay // relays are the nested relays that are not relay clients, see NewBatchRelay
+
+	// stats hold the statistics of each relay client by name, adaptive how they are used to send requests
+	statsMu  sync.Mutex
+	stats    map[string]*relayStats
+	adaptive *AdaptiveOptions
+
+	// breakers hold the circuit breaker of each relay client by name, nil breakerOpts if they are disabled
+	breakerOpts *BreakerOptions
+	breakers    map[string]*breaker
+
+	// shadow holds the shadow mode options, live the names of the relays that are not shadowed
+	shadow *ShadowOptions
+	live   map[string]bool
 }
 
 func NewBatchRelayClient(
@@ -377,15 +583,109 @@ func NewBatchRelayClient(
 	return
 }
 
-// BatchSendBundle sends a Bundle on all connected relay clients
+// NewBatchRelayClientFromRelayClients creates a BatchRelayClient from existing relay clients, use this to batch relay
+// clients that have a simulation endpoint
+func NewBatchRelayClientFromRelayClients(relayClients ...*RelayClient) (b *BatchRelayClient, retErr error) {
+	for _, c := range relayClients {
+		if c == nil {
+			retErr = errors.New("must not provide a nil relay client")
+			return
+		}
+	}
+
+	b = &BatchRelayClient{
+		relayClients: relayClients,
+	}
+	return
+}
+
+// BatchSendBundle sends a Bundle on all connected relay clients, one after another, then on the nested relays
+// concurrently
 func (r *BatchRelayClient) BatchSendBundle(b Bundle) (resps map[string]SendBundleResponse) {
 
 	resps = make(map[string]SendBundleResponse)
 
+	clients, skipped := r.plan()
+	for _, client := range clients {
+		resps[client.Name()] = r.sendBundleTo(context.Background(), client, b, time.Time{})
+	}
+	r.sendNested(context.Background(), b, resps)
+	setSkipped(resps, skipped)
+
+	return
+}
+
+// BatchSendBundleContext sends a Bundle on all connected relay clients concurrently. Requests still in flight when
+// ctx is canceled are aborted and their response holds the context error. Relay clients skipped by the
+// AdaptiveOptions set with SetAdaptive get a response with ErrRelayUnhealthy, those skipped by an open circuit
+// breaker a response with ErrCircuitOpen.
+func (r *BatchRelayClient) BatchSendBundleContext(ctx context.Context, b Bundle) (resps map[string]SendBundleResponse) {
+	clients, skipped := r.plan()
+	resps = r.sendBundleConcurrently(ctx, clients, b, time.Time{})
+	setSkipped(resps, skipped)
+	return
+}
+
+// sendBundleConcurrently sends b to clients and the nested relays of r concurrently, aborting the requests still in
+// flight at deadline if it is not zero
+func (r *BatchRelayClient) sendBundleConcurrently(ctx context.Context, clients []*RelayClient, b Bundle, deadline time.Time) (resps map[string]SendBundleResponse) {
+
+	resps = make(map[string]SendBundleResponse)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func(client *RelayClient) {
+			defer wg.Done()
+			resp := r.sendBundleTo(ctx, client, b, deadline)
+			mu.Lock()
+			resps[client.Name()] = resp
+			mu.Unlock()
+		}(client)
+	}
+	nestedCtx := ctx
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		nestedCtx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	for _, relay := range r.nested() {
+		wg.Add(1)
+		go func(relay Relay) {
+			defer wg.Done()
+			nested := relay.Send(nestedCtx, b)
+			mu.Lock()
+			merge(resps, nested)
+			mu.Unlock()
+		}(relay)
+	}
+	wg.Wait()
+
+	return
+}
+
+// setSkipped sets the response of every skipped relay client to its error
+func setSkipped(resps map[string]SendBundleResponse, skipped map[string]error) {
+	for name, err := range skipped {
+		resps[name] = SendBundleResponse{
+			Error: err,
+		}
+	}
+}
+
+// BatchCancelBundle cancels the bundles sent with replacementUUID on all connected relay clients
+func (r *BatchRelayClient) BatchCancelBundle(replacementUUID string) (resps map[string]SendBundleResponse) {
+
+	resps = make(map[string]SendBundleResponse)
+
 	for _, client := range r.relayClients {
-		resp := client.SendBundle(b)
+		resp := r.cancelBundleOn(context.Background(), client, replacementUUID)
 		resps[client.Name()] = resp
 	}
+	for _, relay := range r.nested() {
+		merge(resps, relay.Cancel(context.Background(), replacementUUID))
+	}
 
 	return
 }
@@ -185,6 +185,53 @@ func Test_preparePayload(t *testing.T) {
 	}
 }
 
+func Test_prepareCallBundlePayload(t *testing.T) {
+	b, err := NewBundle(nil, 12345, 12344, nil, nil, nil)
+	if err != nil {
+		t.Errorf("failed to make new bundle: %+v\n", err)
+		return
+	}
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	r, _ := NewRelayClient(pkey, "", "", "")
+
+	coinbase := common.HexToAddress("0xdafea492d9c6733ae3d56b7ed1adb60692c98bc5")
+	timestamp := uint64(1655000000)
+	tests := []struct {
+		name            string
+		opts            *SimulationOptions
+		wantPayloadJSON string
+	}{
+		{
+			name:            "no options",
+			opts:            nil,
+			wantPayloadJSON: `{"jsonrpc":"2.0","method":"eth_callBundle","params":[{"txs":[],"blockNumber":"0x3039","stateBlockNumber":"0x3038"}],"id":1}`,
+		},
+		{
+			name: "all options",
+			opts: &SimulationOptions{
+				Coinbase:   &coinbase,
+				Timestamp:  &timestamp,
+				GasLimit:   func() *uint64 { a := uint64(30000000); return &a }(),
+				Difficulty: big.NewInt(0),
+				BaseFee:    big.NewInt(12000000000),
+			},
+			wantPayloadJSON: `{"jsonrpc":"2.0","method":"eth_callBundle","params":[{"txs":[],"blockNumber":"0x3039","stateBlockNumber":"0x3038","coinbase":"0xDAFEA492D9c6733ae3d56b7Ed1ADB60692c98Bc5","timestamp":1655000000,"gasLimit":30000000,"difficulty":0,"baseFee":12000000000}],"id":1}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloadBytes, err := r.prepareCallBundlePayload(b, tt.opts)
+			if err != nil {
+				t.Errorf("failed prepareCallBundlePayload: %+v\n", err)
+				return
+			}
+			if string(payloadBytes) != tt.wantPayloadJSON {
+				t.Errorf("wrong json\nwant: %+v\ngot:  %+v\n", tt.wantPayloadJSON, string(payloadBytes))
+			}
+		})
+	}
+}
+
 func TestRelayClient_SendBundle(t *testing.T) {
 	if !*fbLiveTest {
 		t.SkipNow()
@@ -260,7 +307,7 @@ func TestRelayClient_SimulateBundle(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	respBytes, duration, err := r.SimulateBundle(b)
+	respBytes, duration, err := r.SimulateBundle(b, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -328,6 +375,13 @@ func TestExtractExecutionErrorFromBundleResponse(t *testing.T) {
 			},
 			wantErrs: []error{errors.New("err: execution reverted, revertString: y")},
 		},
+		{
+			name: "Test with error without revert",
+			args: args{
+				jsonResponseStr: `{"id":1,"jsonrpc":"2.0","result":{"results":[{"error":"out of gas","txHash":"0xabc8eb8ca3f66072aba73063332edc8d86904febd5f85923cc44d289ecaf2623"}]}}`,
+			},
+			wantErrs: []error{errors.New("err: out of gas")},
+		},
 		{
 			name: "Test simulation error",
 			args: args{
@@ -335,6 +389,13 @@ func TestExtractExecutionErrorFromBundleResponse(t *testing.T) {
 			},
 			wantErrs: []error{errors.New("map[code:%!s(float64=-32000) message:err: nonce too low: address 0x3cA43755058a2294Fb280DfF9127db6F9c2216EA, tx: 31 state: 32; txhash 0xb5fba72f1163ec32218697b50e39ab30039fde4ee894e4ffc233753f4ecb82d7]")},
 		},
+		{
+			name: "Test null result",
+			args: args{
+				jsonResponseStr: `{"id":1,"jsonrpc":"2.0","result":null}`,
+			},
+			wantErrs: []error{errors.New(`no result in response: {"id":1,"jsonrpc":"2.0","result":null}`)},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,319 @@
+package flashbots
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// journalTimeFormat is the suffix of rotated journal files, it sorts in time order
+const journalTimeFormat = "20060102T150405.000000000"
+
+// JournalEntry is a single relay request in a Journal
+type JournalEntry struct {
+	Time           time.Time       `json:"time"`                   // Time the request started
+	Relay          string          `json:"relay"`                  // Relay is the name of the RelayClient
+	Method         string          `json:"method"`                 // Method is the JSON-RPC method
+	Endpoint       string          `json:"endpoint,omitempty"`     // Endpoint served the request, empty if it was never sent
+	SigningAddress common.Address  `json:"signingAddress"`         // SigningAddress signed the request
+	Payload        json.RawMessage `json:"payload"`                // Payload is the JSON-RPC request
+	Response       json.RawMessage `json:"response,omitempty"`     // Response is the JSON-RPC response, if it was valid JSON
+	ResponseText   string          `json:"responseText,omitempty"` // ResponseText is the response if it was not valid JSON
+	Duration       time.Duration   `json:"duration"`               // Duration of the request in nanoseconds
+	Error          string          `json:"error,omitempty"`        // Error of the request, empty if it succeeded
+	DryRun         bool            `json:"dryRun,omitempty"`       // DryRun is true if the request was not sent, see SendBundleResponse
+}
+
+// Bundle decodes the bundle of an eth_sendBundle or eth_callBundle entry, with its Transactions decoded from
+// TxsByteString
+func (e JournalEntry) Bundle() (b Bundle, retErr error) {
+	var payload struct {
+		Params []Bundle `json:"params"`
+	}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		retErr = fmt.Errorf("failed to unmarshal payload: %w", err)
+		return
+	}
+	if len(payload.Params) != 1 || len(payload.Params[0].TxsByteString) == 0 {
+		retErr = fmt.Errorf("no bundle in %s payload", e.Method)
+		return
+	}
+
+	b = payload.Params[0]
+	b.Transactions = make([]*types.Transaction, 0, len(b.TxsByteString))
+	for i, raw := range b.TxsByteString {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(common.FromHex(raw)); err != nil {
+			retErr = fmt.Errorf("failed to decode tx %d: %w", i, err)
+			return
+		}
+		b.Transactions = append(b.Transactions, tx)
+	}
+	return
+}
+
+// JournalOptions configures a Journal. Zero values disable an option.
+type JournalOptions struct {
+	MaxBytes   int64 // MaxBytes rotates the journal file once it grows beyond MaxBytes
+	MaxBackups int   // MaxBackups is the number of rotated files kept, the oldest are deleted first
+
+	// OnError is called with every error writing the journal, may be nil
+	OnError func(error)
+}
+
+// Journal appends every request of the relay clients it is set on to a JSON Lines file. Rotated files are renamed
+// to the journal path with a timestamp suffix. Journal is safe for concurrent use.
+type Journal struct {
+	path string
+	opts JournalOptions
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewJournal opens the journal at path for appending, creating it if needed
+func NewJournal(path string, opts JournalOptions) (j *Journal, retErr error) {
+	j = &Journal{
+		path: path,
+		opts: opts,
+	}
+	if retErr = j.open(); retErr != nil {
+		j = nil
+	}
+	return
+}
+
+func (j *Journal) open() error {
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat journal: %w", err)
+	}
+	j.file = file
+	j.size = info.Size()
+	return nil
+}
+
+// Append writes entry to the journal, rotating it first if it is full
+func (j *Journal) Append(entry JournalEntry) (retErr error) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return errors.New("journal is closed")
+	}
+	if j.opts.MaxBytes > 0 && j.size > 0 && j.size+int64(len(line)) > j.opts.MaxBytes {
+		// entry is still written if the journal is open again after a failed rotation
+		if retErr = j.rotate(); j.file == nil {
+			return
+		}
+	}
+	n, err := j.file.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return
+}
+
+// rotate renames the journal file and opens a new one. If the rename fails the journal file is opened again, j.file
+// is only nil if that failed too. j.mu must be held.
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return fmt.Errorf("failed to close journal: %w", err)
+	}
+	j.file = nil
+	rotated := j.path + "." + time.Now().UTC().Format(journalTimeFormat)
+	if err := os.Rename(j.path, rotated); err != nil {
+		if openErr := j.open(); openErr != nil {
+			return fmt.Errorf("failed to rotate journal: %v, %w", err, openErr)
+		}
+		return fmt.Errorf("failed to rotate journal: %w", err)
+	}
+	if err := j.open(); err != nil {
+		return err
+	}
+
+	if j.opts.MaxBackups > 0 {
+		backups, err := rotatedJournals(j.path)
+		if err != nil {
+			return err
+		}
+		for len(backups) > j.opts.MaxBackups {
+			if err := os.Remove(backups[0]); err != nil {
+				return fmt.Errorf("failed to delete rotated journal: %w", err)
+			}
+			backups = backups[1:]
+		}
+	}
+	return nil
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// record appends a request of r to the journal, reporting failures to OnError
+func (j *Journal) record(r *RelayClient, method string, start time.Time, payload []byte, resp SendBundleResponse) {
+	entry := JournalEntry{
+		Time:           start,
+		Relay:          r.name,
+		Method:         method,
+		Endpoint:       resp.Endpoint,
+		SigningAddress: r.signingPublicAddress,
+		Payload:        payload,
+		Duration:       resp.Duration,
+		DryRun:         resp.DryRun,
+	}
+	if json.Valid(resp.ResponseBytes) {
+		entry.Response = resp.ResponseBytes
+	} else {
+		entry.ResponseText = string(resp.ResponseBytes)
+	}
+	if resp.Error != nil {
+		entry.Error = resp.Error.Error()
+	}
+	if err := j.Append(entry); err != nil && j.opts.OnError != nil {
+		j.opts.OnError(err)
+	}
+}
+
+// SetJournal makes r append every request it sends, except warm-ups, to journal, nil to stop. SetJournal must not
+// be called concurrently with requests.
+func (r *RelayClient) SetJournal(journal *Journal) {
+	r.journal = journal
+}
+
+// SetJournal sets journal on all connected relay clients, see RelayClient.SetJournal
+func (r *BatchRelayClient) SetJournal(journal *Journal) {
+	for _, client := range r.relayClients {
+		client.SetJournal(journal)
+	}
+}
+
+// rotatedJournals returns the rotated files of the journal at path, oldest first. Other files that start with path,
+// e.g. path.txt, are not rotated journals.
+func rotatedJournals(path string) (files []string, retErr error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		retErr = fmt.Errorf("failed to list rotated journals: %w", err)
+		return
+	}
+	for _, name := range matches {
+		if _, err := time.Parse(journalTimeFormat, strings.TrimPrefix(name, path+".")); err == nil {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return
+}
+
+// JournalFiles returns the journal at path and its rotated files, oldest first
+func JournalFiles(path string) (files []string, retErr error) {
+	if files, retErr = rotatedJournals(path); retErr != nil {
+		return
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return
+}
+
+// JournalReader reads the entries of a journal
+type JournalReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewJournalReader creates a reader of the journal entries in r
+func NewJournalReader(r io.Reader) *JournalReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	return &JournalReader{
+		scanner: scanner,
+	}
+}
+
+// Next returns the next entry, io.EOF after the last one
+func (r *JournalReader) Next() (entry JournalEntry, retErr error) {
+	for r.scanner.Scan() {
+		r.line++
+		if len(r.scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(r.scanner.Bytes(), &entry); err != nil {
+			retErr = fmt.Errorf("failed to unmarshal journal line %d: %w", r.line, err)
+		}
+		return
+	}
+	if retErr = r.scanner.Err(); retErr == nil {
+		retErr = io.EOF
+	}
+	return
+}
+
+// ReplayJournal calls fn with every entry of the journal at path and its rotated files, oldest first, stopping at the
+// first error fn returns
+func ReplayJournal(path string, fn func(JournalEntry) error) (retErr error) {
+	files, err := JournalFiles(path)
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		if retErr = replayJournalFile(name, fn); retErr != nil {
+			return
+		}
+	}
+	return
+}
+
+func replayJournalFile(name string, fn func(JournalEntry) error) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	reader := NewJournalReader(file)
+	for {
+		entry, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
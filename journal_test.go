@@ -0,0 +1,178 @@
+package flashbots
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestJournal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "bundles.jsonl")
+	journal, err := NewJournal(path, JournalOptions{OnError: func(err error) { t.Errorf("journal error = %v", err) }})
+	if err != nil {
+		t.Fatalf("NewJournal() error = %v", err)
+	}
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	up, _ := NewRelayClient(pkey, "up", server.URL, "")
+	down, _ := NewRelayClient(pkey, "down", "http://127.0.0.1:1", "")
+	batch, _ := NewBatchRelayClientFromRelayClients(up, down)
+	batch.SetJournal(journal)
+
+	tx := types.MustSignNewTx(pkey, types.NewLondonSigner(big.NewInt(1)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Gas:       21000,
+		GasFeeCap: big.NewInt(100),
+	})
+	b, _ := NewBundle([]*types.Transaction{tx}, 100, 0, nil, nil, nil)
+	batch.BatchSendBundle(b)
+	up.CancelBundle("uuid")
+	if err := journal.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	entries := make([]JournalEntry, 0)
+	err = ReplayJournal(path, func(e JournalEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReplayJournal() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("ReplayJournal() entries = %v, want 3", len(entries))
+	}
+
+	sent := entries[0]
+	if sent.Relay != "up" || sent.Method != "eth_sendBundle" || sent.Endpoint != server.URL || sent.Error != "" ||
+		sent.SigningAddress != up.SigningAddress() || len(sent.Response) == 0 || sent.Duration <= 0 {
+		t.Errorf("eth_sendBundle entry = %+v", sent)
+	}
+	if result, err := ParseSendBundleResponse(sent.Response); err != nil || result.BundleHash.Hex() != "0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65" {
+		t.Errorf("ParseSendBundleResponse() of journaled response = %v, %v", result, err)
+	}
+	journaled, err := sent.Bundle()
+	if err != nil || len(journaled.Transactions) != 1 || journaled.Transactions[0].Hash() != tx.Hash() || journaled.BlockNumber != b.BlockNumber {
+		t.Errorf("Bundle() = %+v, %v, want the sent bundle", journaled, err)
+	}
+
+	if failed := entries[1]; failed.Relay != "down" || failed.Error == "" || len(failed.Response) != 0 {
+		t.Errorf("failed entry = %+v", failed)
+	}
+	if canceled := entries[2]; canceled.Method != "eth_cancelBundle" {
+		t.Errorf("cancel entry = %+v", canceled)
+	}
+	if _, err := entries[2].Bundle(); err == nil {
+		t.Errorf("Bundle() of an eth_cancelBundle entry error = nil")
+	}
+}
+
+func TestJournal_rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundles.jsonl")
+	journal, err := NewJournal(path, JournalOptions{MaxBytes: 500, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewJournal() error = %v", err)
+	}
+	defer journal.Close()
+
+	for i := 0; i < 8; i++ {
+		err := journal.Append(JournalEntry{
+			Relay:   "relay",
+			Method:  "eth_sendBundle",
+			Payload: []byte(`{"jsonrpc":"2.0","method":"eth_sendBundle","params":[],"id":1}`),
+			Error:   string(rune('a' + i)),
+		})
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	files, err := JournalFiles(path)
+	if err != nil || len(files) != 3 || files[2] != path {
+		t.Fatalf("JournalFiles() = %v, %v, want 2 backups and the journal", files, err)
+	}
+	replayed := ""
+	_ = ReplayJournal(path, func(e JournalEntry) error {
+		replayed += e.Error
+		return nil
+	})
+	if replayed != "cdefgh" {
+		t.Errorf("ReplayJournal() = %v, want the entries of the kept files in order", replayed)
+	}
+}
+
+func TestJournal_rotate_siblingFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundles.jsonl")
+	siblings := []string{path + ".txt", path + ".1"}
+	for _, name := range siblings {
+		if err := os.WriteFile(name, []byte("notes\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	journal, err := NewJournal(path, JournalOptions{MaxBytes: 100, MaxBackups: 1})
+	if err != nil {
+		t.Fatalf("NewJournal() error = %v", err)
+	}
+	defer journal.Close()
+
+	for i := 0; i < 4; i++ {
+		if err := journal.Append(JournalEntry{Relay: "relay", Method: "eth_sendBundle"}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	for _, name := range siblings {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("rotation deleted %s: %v", name, err)
+		}
+	}
+	files, err := JournalFiles(path)
+	if err != nil || len(files) != 2 || files[0] == siblings[0] || files[0] == siblings[1] || files[1] != path {
+		t.Errorf("JournalFiles() = %v, %v, want 1 backup and the journal", files, err)
+	}
+	if err := ReplayJournal(path, func(JournalEntry) error { return nil }); err != nil {
+		t.Errorf("ReplayJournal() error = %v", err)
+	}
+}
+
+func TestJournal_rotate_renameFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundles.jsonl")
+	journal, err := NewJournal(path, JournalOptions{MaxBytes: 100})
+	if err != nil {
+		t.Fatalf("NewJournal() error = %v", err)
+	}
+	defer journal.Close()
+
+	if err := journal.Append(JournalEntry{Relay: "relay", Error: "a"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	// the rename of the next rotation fails with the journal file gone
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Append(JournalEntry{Relay: "relay", Error: "b"}); err == nil {
+		t.Error("Append() with a failed rotation error = nil")
+	}
+	if err := journal.Append(JournalEntry{Relay: "relay", Error: "c"}); err != nil {
+		t.Errorf("Append() after a failed rotation error = %v", err)
+	}
+
+	replayed := ""
+	_ = ReplayJournal(path, func(e JournalEntry) error {
+		replayed += e.Error
+		return nil
+	})
+	if replayed != "bc" {
+		t.Errorf("ReplayJournal() = %v, want the entries written after the failed rotation", replayed)
+	}
+}
@@ -0,0 +1,192 @@
+// Package localsim simulates flashbots bundles locally on top of a go-ethereum state, without a mev-geth
+// simulation endpoint. Results have the same shape as an eth_callBundle response.
+package localsim
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/wphan/go-flashbots"
+)
+
+type Simulator struct {
+	config   *params.ChainConfig
+	chain    core.ChainContext // chain resolves the BLOCKHASH opcode, may be nil
+	vmConfig vm.Config
+}
+
+// NewSimulator creates a new local bundle simulator
+// config: chain config of the chain being simulated, e.g. params.MainnetChainConfig
+// chain:  chain used to look up block hashes, e.g. SimulatedBackend.Blockchain(). if nil, only the parent block hash is known
+func NewSimulator(config *params.ChainConfig, chain core.ChainContext) (s *Simulator, retErr error) {
+	if config == nil {
+		retErr = errors.New("must provide a chain config")
+		return
+	}
+	s = &Simulator{
+		config: config,
+		chain:  chain,
+	}
+	return
+}
+
+// SimulateBundle executes the transactions of b sequentially on a copy of statedb, in a block built on top of parent
+// the same way mev-geth's eth_callBundle does. statedb must be the state after parent, and is not modified.
+// opts: block context overrides for the simulation, nil to use the values derived from parent
+func (s *Simulator) SimulateBundle(statedb *state.StateDB, parent *types.Header, b flashbots.Bundle, opts *flashbots.SimulationOptions) (result flashbots.CallBundleResult, retErr error) {
+	if statedb == nil || parent == nil {
+		retErr = errors.New("must provide a statedb and a parent header")
+		return
+	}
+	header, err := s.simulationHeader(parent, b, opts)
+	if err != nil {
+		retErr = err
+		return
+	}
+
+	statedb = statedb.Copy()
+	signer := types.MakeSigner(s.config, header.Number)
+	blockContext := core.NewEVMBlockContext(header, s.chain, &header.Coinbase)
+	if s.chain == nil {
+		blockContext.GetHash = func(n uint64) common.Hash {
+			if n+1 == header.Number.Uint64() {
+				return header.ParentHash
+			}
+			return common.Hash{}
+		}
+	}
+	evm := vm.NewEVM(blockContext, vm.TxContext{}, statedb, s.config, s.vmConfig)
+	gasPool := new(core.GasPool).AddGas(header.GasLimit)
+
+	coinbaseBalanceBefore := statedb.GetBalance(header.Coinbase)
+	gasFees := new(big.Int)
+	txHashes := make([]byte, 0, len(b.Transactions)*common.HashLength)
+	result.Results = make([]flashbots.CallBundleTxResult, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		txResult, err := s.applyTransaction(evm, statedb, gasPool, signer, header, tx, i)
+		if err != nil {
+			retErr = fmt.Errorf("err: %w; txhash %s", err, tx.Hash().Hex())
+			return
+		}
+		result.Results[i] = txResult
+		result.TotalGasUsed += txResult.GasUsed
+		gasFees.Add(gasFees, txResult.GasFees)
+		txHashes = append(txHashes, tx.Hash().Bytes()...)
+	}
+
+	result.BundleHash = crypto.Keccak256Hash(txHashes)
+	result.CoinbaseDiff = new(big.Int).Sub(statedb.GetBalance(header.Coinbase), coinbaseBalanceBefore)
+	result.GasFees = gasFees
+	result.EthSentToCoinbase = new(big.Int).Sub(result.CoinbaseDiff, gasFees)
+	result.BundleGasPrice = new(big.Int)
+	if result.TotalGasUsed > 0 {
+		result.BundleGasPrice.Div(result.CoinbaseDiff, new(big.Int).SetUint64(result.TotalGasUsed))
+	}
+	result.StateBlockNumber = parent.Number.Uint64()
+	return
+}
+
+func (s *Simulator) applyTransaction(evm *vm.EVM, statedb *state.StateDB, gasPool *core.GasPool, signer types.Signer,
+	header *types.Header, tx *types.Transaction, txIndex int) (txResult flashbots.CallBundleTxResult, retErr error) {
+
+	msg, err := tx.AsMessage(signer, header.BaseFee)
+	if err != nil {
+		retErr = err
+		return
+	}
+	gasPrice, err := tx.EffectiveGasTip(header.BaseFee)
+	if err != nil {
+		retErr = err
+		return
+	}
+
+	coinbaseBalanceBefore := statedb.GetBalance(header.Coinbase)
+	statedb.Prepare(tx.Hash(), txIndex)
+	evm.Reset(core.NewEVMTxContext(msg), statedb)
+	execResult, err := core.ApplyMessage(evm, msg, gasPool)
+	if err != nil {
+		retErr = err
+		return
+	}
+	if s.config.IsByzantium(header.Number) {
+		statedb.Finalise(true)
+	} else {
+		statedb.IntermediateRoot(s.config.IsEIP158(header.Number))
+	}
+
+	txResult.TxHash = tx.Hash()
+	txResult.FromAddress = msg.From()
+	if tx.To() != nil {
+		txResult.ToAddress = *tx.To()
+	}
+	txResult.GasUsed = execResult.UsedGas
+	txResult.GasPrice = gasPrice
+	txResult.GasFees = new(big.Int).Mul(new(big.Int).SetUint64(execResult.UsedGas), gasPrice)
+	txResult.CoinbaseDiff = new(big.Int).Sub(statedb.GetBalance(header.Coinbase), coinbaseBalanceBefore)
+	txResult.EthSentToCoinbase = new(big.Int).Sub(txResult.CoinbaseDiff, txResult.GasFees)
+	if execResult.Err != nil {
+		txResult.Error = execResult.Err.Error()
+		// hex encoded, raw revert data is rarely valid UTF-8, see CallBundleResult.ExecutionErrors
+		if revert := execResult.Revert(); len(revert) > 0 {
+			txResult.Revert = hexutil.Encode(revert)
+		}
+	} else {
+		txResult.Value = execResult.Return()
+	}
+	return
+}
+
+// simulationHeader derives the header of the simulated block from its parent, like eth_callBundle
+func (s *Simulator) simulationHeader(parent *types.Header, b flashbots.Bundle, opts *flashbots.SimulationOptions) (header *types.Header, retErr error) {
+	blockNumber := new(big.Int).Add(parent.Number, common.Big1)
+	if b.BlockNumber != "" {
+		n, err := hexutil.DecodeUint64(b.BlockNumber)
+		if err != nil {
+			retErr = fmt.Errorf("failed to decode bundle block number %s: %w", b.BlockNumber, err)
+			return
+		}
+		blockNumber.SetUint64(n)
+	}
+
+	header = &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   parent.Coinbase,
+		Number:     blockNumber,
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		MixDigest:  parent.MixDigest,
+	}
+	if s.config.IsLondon(blockNumber) {
+		header.BaseFee = misc.CalcBaseFee(s.config, parent)
+	}
+
+	if opts != nil {
+		if opts.Coinbase != nil {
+			header.Coinbase = *opts.Coinbase
+		}
+		if opts.Timestamp != nil {
+			header.Time = *opts.Timestamp
+		}
+		if opts.GasLimit != nil {
+			header.GasLimit = *opts.GasLimit
+		}
+		if opts.Difficulty != nil {
+			header.Difficulty = new(big.Int).Set(opts.Difficulty)
+		}
+		if opts.BaseFee != nil {
+			header.BaseFee = new(big.Int).Set(opts.BaseFee)
+		}
+	}
+	return
+}
@@ -0,0 +1,132 @@
+package localsim
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/wphan/go-flashbots"
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestSimulator_SimulateBundle(t *testing.T) {
+	pkey, pubAddr, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		pubAddr: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+	}, 30000000)
+	defer backend.Close()
+
+	chain := backend.Blockchain()
+	parent := chain.CurrentBlock().Header()
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coinbase := common.HexToAddress("0xdafea492d9c6733ae3d56b7ed1adb60692c98bc5")
+	baseFee := big.NewInt(params.GWei)
+	signer := types.LatestSigner(chain.Config())
+	tx, _ := types.SignNewTx(pkey, signer, &types.DynamicFeeTx{
+		ChainID:   chain.Config().ChainID,
+		Nonce:     0,
+		GasTipCap: big.NewInt(2 * params.GWei),
+		GasFeeCap: big.NewInt(10 * params.GWei),
+		Gas:       21000,
+		To:        &pubAddr,
+		Value:     big.NewInt(0),
+	})
+	payment, _ := types.SignNewTx(pkey, signer, &types.DynamicFeeTx{
+		ChainID:   chain.Config().ChainID,
+		Nonce:     1,
+		GasTipCap: big.NewInt(0),
+		GasFeeCap: big.NewInt(10 * params.GWei),
+		Gas:       21000,
+		To:        &coinbase,
+		Value:     big.NewInt(params.Ether),
+	})
+	b, err := flashbots.NewBundle([]*types.Transaction{tx, payment}, parent.Number.Uint64()+1, parent.Number.Uint64(), nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSimulator(chain.Config(), chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.SimulateBundle(statedb, parent, b, &flashbots.SimulationOptions{Coinbase: &coinbase, BaseFee: baseFee})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.TotalGasUsed != 42000 {
+		t.Errorf("SimulateBundle() TotalGasUsed = %v, want %v", result.TotalGasUsed, 42000)
+	}
+	wantGasFees := big.NewInt(21000 * 2 * params.GWei)
+	if result.GasFees.Cmp(wantGasFees) != 0 {
+		t.Errorf("SimulateBundle() GasFees = %v, want %v", result.GasFees, wantGasFees)
+	}
+	if result.EthSentToCoinbase.Cmp(big.NewInt(params.Ether)) != 0 {
+		t.Errorf("SimulateBundle() EthSentToCoinbase = %v, want %v", result.EthSentToCoinbase, params.Ether)
+	}
+	wantCoinbaseDiff := new(big.Int).Add(wantGasFees, big.NewInt(params.Ether))
+	if result.CoinbaseDiff.Cmp(wantCoinbaseDiff) != 0 {
+		t.Errorf("SimulateBundle() CoinbaseDiff = %v, want %v", result.CoinbaseDiff, wantCoinbaseDiff)
+	}
+	wantGasPrice := new(big.Int).Div(wantCoinbaseDiff, big.NewInt(42000))
+	if result.EffectiveGasPrice().Cmp(wantGasPrice) != 0 {
+		t.Errorf("SimulateBundle() EffectiveGasPrice = %v, want %v", result.EffectiveGasPrice(), wantGasPrice)
+	}
+	if len(result.Results) != 2 || result.Results[0].Failed() || result.Results[1].FromAddress != pubAddr {
+		t.Errorf("SimulateBundle() Results = %+v", result.Results)
+	}
+	if statedb.GetNonce(pubAddr) != 0 {
+		t.Errorf("SimulateBundle() modified the provided statedb")
+	}
+
+	// the same bundle fails to simulate once its first transaction is invalid
+	_, err = s.SimulateBundle(statedb, parent, flashbots.Bundle{Transactions: []*types.Transaction{payment}}, nil)
+	if err == nil {
+		t.Errorf("SimulateBundle() error = nil, want nonce error")
+	}
+}
+
+func TestSimulator_SimulateBundle_Revert(t *testing.T) {
+	pkey, pubAddr, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		pubAddr: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+		// contract that always reverts with Error("no")
+		common.HexToAddress("0x1111111111111111111111111111111111111111"): {
+			Balance: big.NewInt(0),
+			Code:    common.FromHex("0x7f08c379a000000000000000000000000000000000000000000000000000000000600052602060045260026024527f6e6f00000000000000000000000000000000000000000000000000000000000060445260646000fd"),
+		},
+	}, 30000000)
+	defer backend.Close()
+
+	chain := backend.Blockchain()
+	parent := chain.CurrentBlock().Header()
+	statedb, _ := chain.State()
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tx, _ := types.SignNewTx(pkey, types.LatestSigner(chain.Config()), &types.DynamicFeeTx{
+		ChainID:   chain.Config().ChainID,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: big.NewInt(10 * params.GWei),
+		Gas:       100000,
+		To:        &to,
+	})
+
+	s, _ := NewSimulator(chain.Config(), nil)
+	result, err := s.SimulateBundle(statedb, parent, flashbots.Bundle{Transactions: []*types.Transaction{tx}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Results[0].Failed() || result.Results[0].Error != "execution reverted" {
+		t.Fatalf("SimulateBundle() Results[0] = %+v, want reverted", result.Results[0])
+	}
+	if errs := result.ExecutionErrors(nil); len(errs) != 1 || errs[0].Reason != `Error("no")` {
+		t.Errorf("ExecutionErrors() = %+v, want the revert reason Error(\"no\")", errs)
+	}
+}
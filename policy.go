@@ -0,0 +1,154 @@
+package flashbots
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SimulationPolicy decides whether a simulated bundle is worth sending. Zero values disable a check.
+type SimulationPolicy struct {
+	RequireNoReverts     bool     // RequireNoReverts rejects bundles with failed transactions not listed in Bundle.RevertingTxHashes
+	MinCoinbaseDiff      *big.Int // MinCoinbaseDiff is the minimum coinbase balance change of the bundle
+	MinEffectiveGasPrice *big.Int // MinEffectiveGasPrice is the minimum effective bundle gas price
+	MaxTotalGas          uint64   // MaxTotalGas is the maximum gas the bundle may use
+
+	// SimulationOptions are passed on to SimulateBundle, nil to simulate on top of the state block as is
+	SimulationOptions *SimulationOptions
+	// RevertDecoder decodes the revert reason reported in a RejectionReverted rejection, may be nil
+	RevertDecoder *RevertDecoder
+}
+
+type RejectionReason int
+
+const (
+	RejectionReverted RejectionReason = iota
+	RejectionCoinbaseDiff
+	RejectionEffectiveGasPrice
+	RejectionTotalGas
+)
+
+func (r RejectionReason) String() string {
+	switch r {
+	case RejectionReverted:
+		return "reverted"
+	case RejectionCoinbaseDiff:
+		return "coinbaseDiff"
+	case RejectionEffectiveGasPrice:
+		return "effectiveGasPrice"
+	case RejectionTotalGas:
+		return "totalGas"
+	default:
+		return "unknown"
+	}
+}
+
+// PolicyRejection is returned by SimulateAndSend when a simulated bundle does not satisfy the SimulationPolicy
+type PolicyRejection struct {
+	Reason  RejectionReason
+	Message string
+}
+
+func (p *PolicyRejection) Error() string {
+	return fmt.Sprintf("bundle rejected by policy (%s): %s", p.Reason, p.Message)
+}
+
+// Check returns a *PolicyRejection if the simulation result of b does not satisfy the policy
+func (p SimulationPolicy) Check(b Bundle, result CallBundleResult) error {
+	if p.RequireNoReverts {
+		allowed := make(map[common.Hash]bool, len(b.RevertingTxHashes))
+		for _, h := range b.RevertingTxHashes {
+			allowed[common.HexToHash(h)] = true
+		}
+		for _, txErr := range result.ExecutionErrors(p.RevertDecoder) {
+			if !allowed[txErr.TxHash] {
+				return &PolicyRejection{
+					Reason:  RejectionReverted,
+					Message: txErr.Error(),
+				}
+			}
+		}
+	}
+
+	if p.MinCoinbaseDiff != nil {
+		coinbaseDiff := result.CoinbaseDiff
+		if coinbaseDiff == nil {
+			coinbaseDiff = new(big.Int)
+		}
+		if coinbaseDiff.Cmp(p.MinCoinbaseDiff) < 0 {
+			return &PolicyRejection{
+				Reason:  RejectionCoinbaseDiff,
+				Message: fmt.Sprintf("coinbaseDiff %s below minimum %s", coinbaseDiff, p.MinCoinbaseDiff),
+			}
+		}
+	}
+
+	if p.MinEffectiveGasPrice != nil {
+		gasPrice := result.EffectiveGasPrice()
+		if gasPrice.Cmp(p.MinEffectiveGasPrice) < 0 {
+			return &PolicyRejection{
+				Reason:  RejectionEffectiveGasPrice,
+				Message: fmt.Sprintf("effective gas price %s below minimum %s", gasPrice, p.MinEffectiveGasPrice),
+			}
+		}
+	}
+
+	if p.MaxTotalGas != 0 && result.TotalGasUsed > p.MaxTotalGas {
+		return &PolicyRejection{
+			Reason:  RejectionTotalGas,
+			Message: fmt.Sprintf("total gas used %d above maximum %d", result.TotalGasUsed, p.MaxTotalGas),
+		}
+	}
+
+	return nil
+}
+
+// SimulateAndSend simulates b and sends it if the simulation result satisfies policy. If the bundle is rejected,
+// retErr is a *PolicyRejection and simulation holds the result that was rejected.
+func (r *RelayClient) SimulateAndSend(ctx context.Context, b Bundle, policy SimulationPolicy) (simulation CallBundleResult, resp SendBundleResponse, retErr error) {
+	simulation, retErr = simulateAndCheck(ctx, r, b, policy)
+	if retErr != nil {
+		return
+	}
+
+	resp = r.sendBundle(ctx, b)
+	return
+}
+
+// SimulateAndSend simulates b as Simulate does and sends it on all relays if the simulation result satisfies
+// policy. If the bundle is rejected, retErr is a *PolicyRejection.
+func (r *BatchRelayClient) SimulateAndSend(ctx context.Context, b Bundle, policy SimulationPolicy) (simulation CallBundleResult, resps map[string]SendBundleResponse, retErr error) {
+	simulation, retErr = simulateAndCheck(ctx, r, b, policy)
+	if retErr != nil {
+		return
+	}
+
+	resps = make(map[string]SendBundleResponse)
+	clients, skipped := r.plan()
+	for _, client := range clients {
+		resps[client.Name()] = r.sendBundleTo(ctx, client, b, time.Time{})
+	}
+	r.sendNested(ctx, b, resps)
+	setSkipped(resps, skipped)
+	return
+}
+
+func simulateAndCheck(ctx context.Context, r Relay, b Bundle, policy SimulationPolicy) (simulation CallBundleResult, retErr error) {
+	resp := r.Simulate(ctx, b, policy.SimulationOptions)
+	if resp.Error != nil {
+		retErr = fmt.Errorf("failed to simulate bundle: %w", resp.Error)
+		return
+	}
+
+	simulation, err := ParseCallBundleResponse(resp.ResponseBytes)
+	if err != nil {
+		retErr = fmt.Errorf("failed to parse simulation: %w", err)
+		return
+	}
+
+	retErr = policy.Check(b, simulation)
+	return
+}
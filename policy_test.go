@@ -0,0 +1,132 @@
+package flashbots
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/wphan/go-flashbots/account"
+)
+
+const testCallBundleResponse = `{"jsonrpc":"2.0","id":1,"result":{"bundleGasPrice":"476190476193","bundleHash":"0x73b1e258c7a42fd0230b2fd05529c5d4b6fcb66c227783f8bece8aeacdd1db2e","coinbaseDiff":"20000000000126000","ethSentToCoinbase":"20000000000000000","gasFees":"126000","results":[{"coinbaseDiff":"10000000000063000","ethSentToCoinbase":"10000000000000000","fromAddress":"0x02A727155aeF8609c9f7F2179b2a1f560B39F5A0","gasFees":"63000","gasPrice":"476190476193","gasUsed":21000,"toAddress":"0x73625f59CAdc5009Cb458B751b3E7b6b48C06f2C","txHash":"0x669b4704a7d993a946cdd6e2f95233f308ce0c4649d2e04944e8299efcaa098a","value":"0x"},{"coinbaseDiff":"10000000000063000","error":"execution reverted","ethSentToCoinbase":"10000000000000000","fromAddress":"0x02A727155aeF8609c9f7F2179b2a1f560B39F5A0","gasFees":"63000","gasPrice":"476190476193","gasUsed":21000,"toAddress":"0x73625f59CAdc5009Cb458B751b3E7b6b48C06f2C","txHash":"0xa839ee83465657cac01adc1d50d96c1b586ed498120a84a64749c0034b4f19fa","value":"0x"}],"stateBlockNumber":5221585,"totalGasUsed":42000}}`
+
+func TestSimulationPolicy_Check(t *testing.T) {
+	result, err := ParseCallBundleResponse([]byte(testCallBundleResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		policy     SimulationPolicy
+		bundle     Bundle
+		wantReason *RejectionReason
+	}{
+		{
+			name:   "empty policy",
+			policy: SimulationPolicy{},
+		},
+		{
+			name:       "reverted",
+			policy:     SimulationPolicy{RequireNoReverts: true},
+			wantReason: func() *RejectionReason { r := RejectionReverted; return &r }(),
+		},
+		{
+			name:   "allowed revert",
+			policy: SimulationPolicy{RequireNoReverts: true},
+			bundle: Bundle{RevertingTxHashes: []string{"0xa839ee83465657cac01adc1d50d96c1b586ed498120a84a64749c0034b4f19fa"}},
+		},
+		{
+			name:       "coinbaseDiff too low",
+			policy:     SimulationPolicy{MinCoinbaseDiff: big.NewInt(30000000000000000)},
+			wantReason: func() *RejectionReason { r := RejectionCoinbaseDiff; return &r }(),
+		},
+		{
+			name:       "effective gas price too low",
+			policy:     SimulationPolicy{MinEffectiveGasPrice: big.NewInt(500000000000)},
+			wantReason: func() *RejectionReason { r := RejectionEffectiveGasPrice; return &r }(),
+		},
+		{
+			name:       "too much gas",
+			policy:     SimulationPolicy{MaxTotalGas: 21000},
+			wantReason: func() *RejectionReason { r := RejectionTotalGas; return &r }(),
+		},
+		{
+			name: "all satisfied",
+			policy: SimulationPolicy{
+				MinCoinbaseDiff:      big.NewInt(20000000000000000),
+				MinEffectiveGasPrice: big.NewInt(476190476193),
+				MaxTotalGas:          42000,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.bundle, result)
+			if tt.wantReason == nil {
+				if err != nil {
+					t.Errorf("Check() error = %v, want nil", err)
+				}
+				return
+			}
+			var rejection *PolicyRejection
+			if !errors.As(err, &rejection) {
+				t.Fatalf("Check() error = %v, want *PolicyRejection", err)
+			}
+			if rejection.Reason != *tt.wantReason {
+				t.Errorf("Check() reason = %v, want %v", rejection.Reason, *tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestBatchRelayClient_SimulateAndSend(t *testing.T) {
+	var sendCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		var payload rpcPaylod
+		_ = json.Unmarshal(body, &payload)
+		switch payload.Method {
+		case "eth_callBundle":
+			_, _ = w.Write([]byte(testCallBundleResponse))
+		case "eth_sendBundle":
+			sendCount++
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x73b1e258c7a42fd0230b2fd05529c5d4b6fcb66c227783f8bece8aeacdd1db2e"}}`))
+		}
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	simulator, _ := NewRelayClient(pkey, "simulator", server.URL, server.URL)
+	relay, _ := NewRelayClient(pkey, "relay", server.URL, "")
+	batch, err := NewBatchRelayClientFromRelayClients(relay, simulator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewBundle(nil, 5221586, 5221585, nil, nil, []common.Hash{})
+
+	_, resps, err := batch.SimulateAndSend(context.Background(), b, SimulationPolicy{RequireNoReverts: true})
+	var rejection *PolicyRejection
+	if !errors.As(err, &rejection) {
+		t.Fatalf("SimulateAndSend() error = %v, want *PolicyRejection", err)
+	}
+	if resps != nil || sendCount != 0 {
+		t.Fatalf("SimulateAndSend() sent a rejected bundle")
+	}
+
+	simulation, resps, err := batch.SimulateAndSend(context.Background(), b, SimulationPolicy{MaxTotalGas: 50000})
+	if err != nil {
+		t.Fatalf("SimulateAndSend() error = %v", err)
+	}
+	if simulation.TotalGasUsed != 42000 {
+		t.Errorf("SimulateAndSend() TotalGasUsed = %v, want %v", simulation.TotalGasUsed, 42000)
+	}
+	if len(resps) != 2 || sendCount != 2 {
+		t.Errorf("SimulateAndSend() sent to %d relays, want %d", sendCount, 2)
+	}
+}
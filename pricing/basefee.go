@@ -0,0 +1,122 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/wphan/go-flashbots"
+)
+
+// BaseFeeRange is the range the base fee of a future block can be in
+type BaseFeeRange struct {
+	BlockNumber uint64
+	Min         *big.Int // Min is the base fee if every block until BlockNumber is empty
+	Max         *big.Int // Max is the base fee if every block until BlockNumber is full
+}
+
+// PredictBaseFee predicts the base fee range of blockNumber from the header of an earlier block with the EIP-1559
+// formula. The base fee of the block right after parent is exact, every further block widens the range by 12.5%.
+func PredictBaseFee(parent *types.Header, blockNumber uint64) (r BaseFeeRange, retErr error) {
+	if parent == nil || parent.BaseFee == nil {
+		retErr = errors.New("parent header has no base fee")
+		return
+	}
+	if blockNumber <= parent.Number.Uint64() {
+		retErr = fmt.Errorf("block %d is not after parent block %d", blockNumber, parent.Number.Uint64())
+		return
+	}
+
+	gasTarget := parent.GasLimit / params.ElasticityMultiplier
+	next := nextBaseFee(parent.BaseFee, gasTarget, parent.GasUsed)
+
+	r.BlockNumber = blockNumber
+	r.Min = new(big.Int).Set(next)
+	r.Max = new(big.Int).Set(next)
+	for n := parent.Number.Uint64() + 1; n < blockNumber; n++ {
+		r.Min = nextBaseFee(r.Min, gasTarget, 0)
+		r.Max = nextBaseFee(r.Max, gasTarget, gasTarget*params.ElasticityMultiplier)
+	}
+	return
+}
+
+// nextBaseFee is the EIP-1559 base fee of the block after a block with baseFee that used gasUsed
+func nextBaseFee(baseFee *big.Int, gasTarget, gasUsed uint64) *big.Int {
+	if gasTarget == 0 || gasUsed == gasTarget {
+		return new(big.Int).Set(baseFee)
+	}
+
+	denominator := new(big.Int).SetUint64(gasTarget * params.BaseFeeChangeDenominator)
+	if gasUsed > gasTarget {
+		delta := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(gasUsed-gasTarget))
+		delta.Div(delta, denominator)
+		if delta.Cmp(common.Big1) < 0 {
+			delta.Set(common.Big1)
+		}
+		return delta.Add(baseFee, delta)
+	}
+
+	delta := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(gasTarget-gasUsed))
+	delta.Div(delta, denominator)
+	delta.Sub(baseFee, delta)
+	if delta.Sign() < 0 {
+		delta.SetUint64(0)
+	}
+	return delta
+}
+
+// FeeCapIssue is a transaction whose fee cap may be below the base fee of the block its bundle targets. The fee cap
+// of legacy and access list transactions is their gas price.
+type FeeCapIssue struct {
+	Index   int
+	TxHash  common.Hash
+	TxType  uint8
+	FeeCap  *big.Int
+	BaseFee BaseFeeRange
+
+	// Insufficient is true if the fee cap is below the lowest possible base fee, the transaction can not be included.
+	// Otherwise the transaction is only included if the base fee does not rise above its fee cap.
+	Insufficient bool
+}
+
+func (i FeeCapIssue) Error() string {
+	if i.Insufficient {
+		return fmt.Sprintf("tx %d (%s): fee cap %s below minimum base fee %s of block %d", i.Index, i.TxHash.Hex(), i.FeeCap, i.BaseFee.Min, i.BaseFee.BlockNumber)
+	}
+	return fmt.Sprintf("tx %d (%s): fee cap %s below maximum base fee %s of block %d", i.Index, i.TxHash.Hex(), i.FeeCap, i.BaseFee.Max, i.BaseFee.BlockNumber)
+}
+
+// CheckFeeCaps returns a FeeCapIssue for every transaction in b whose fee cap is below the worst case base fee of the
+// bundle's target block, predicted from parent. Reject the bundle if any issue is Insufficient.
+func CheckFeeCaps(b flashbots.Bundle, parent *types.Header) (issues []FeeCapIssue, retErr error) {
+	blockNumber, err := hexutil.DecodeUint64(b.BlockNumber)
+	if err != nil {
+		retErr = fmt.Errorf("failed to decode bundle block number %s: %w", b.BlockNumber, err)
+		return
+	}
+	baseFee, err := PredictBaseFee(parent, blockNumber)
+	if err != nil {
+		retErr = err
+		return
+	}
+
+	issues = make([]FeeCapIssue, 0)
+	for i, tx := range b.Transactions {
+		if tx.GasFeeCap().Cmp(baseFee.Max) >= 0 {
+			continue
+		}
+		issues = append(issues, FeeCapIssue{
+			Index:        i,
+			TxHash:       tx.Hash(),
+			TxType:       tx.Type(),
+			FeeCap:       new(big.Int).Set(tx.GasFeeCap()),
+			BaseFee:      baseFee,
+			Insufficient: tx.GasFeeCap().Cmp(baseFee.Min) < 0,
+		})
+	}
+	return
+}
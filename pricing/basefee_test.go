@@ -0,0 +1,109 @@
+package pricing
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/wphan/go-flashbots"
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestPredictBaseFee(t *testing.T) {
+	tests := []struct {
+		name        string
+		gasUsed     uint64
+		blockNumber uint64
+		wantMin     *big.Int
+		wantMax     *big.Int
+	}{
+		{
+			name:        "next block at target",
+			gasUsed:     15000000,
+			blockNumber: 15000001,
+			wantMin:     big.NewInt(10 * params.GWei),
+			wantMax:     big.NewInt(10 * params.GWei),
+		},
+		{
+			name:        "next block after full block",
+			gasUsed:     30000000,
+			blockNumber: 15000001,
+			wantMin:     big.NewInt(11250000000),
+			wantMax:     big.NewInt(11250000000),
+		},
+		{
+			name:        "two blocks after target",
+			gasUsed:     15000000,
+			blockNumber: 15000002,
+			wantMin:     big.NewInt(8750000000),
+			wantMax:     big.NewInt(11250000000),
+		},
+		{
+			name:        "three blocks after empty block",
+			gasUsed:     0,
+			blockNumber: 15000003,
+			wantMin:     big.NewInt(6699218750),
+			wantMax:     big.NewInt(11074218750),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := &types.Header{
+				Number:   big.NewInt(15000000),
+				GasLimit: 30000000,
+				GasUsed:  tt.gasUsed,
+				BaseFee:  big.NewInt(10 * params.GWei),
+			}
+			got, err := PredictBaseFee(parent, tt.blockNumber)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Min.Cmp(tt.wantMin) != 0 || got.Max.Cmp(tt.wantMax) != 0 {
+				t.Errorf("PredictBaseFee() = [%v, %v], want [%v, %v]", got.Min, got.Max, tt.wantMin, tt.wantMax)
+			}
+			if next := misc.CalcBaseFee(params.MainnetChainConfig, parent); tt.blockNumber == 15000001 && next.Cmp(got.Min) != 0 {
+				t.Errorf("PredictBaseFee() = %v, misc.CalcBaseFee() = %v", got.Min, next)
+			}
+		})
+	}
+
+	if _, err := PredictBaseFee(&types.Header{Number: big.NewInt(1)}, 2); err == nil {
+		t.Errorf("PredictBaseFee() error = nil for a pre-London parent")
+	}
+}
+
+func TestCheckFeeCaps(t *testing.T) {
+	pkey, pubAddr, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	txs := []*types.Transaction{
+		types.MustSignNewTx(pkey, signer, &types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 0, GasFeeCap: big.NewInt(20 * params.GWei), Gas: 21000, To: &pubAddr}),
+		types.MustSignNewTx(pkey, signer, &types.AccessListTx{ChainID: big.NewInt(1), Nonce: 1, GasPrice: big.NewInt(10 * params.GWei), Gas: 21000, To: &pubAddr}),
+		types.MustSignNewTx(pkey, signer, &types.LegacyTx{Nonce: 2, GasPrice: big.NewInt(8 * params.GWei), Gas: 21000, To: &pubAddr}),
+	}
+	parent := &types.Header{
+		Number:   big.NewInt(15000000),
+		GasLimit: 30000000,
+		GasUsed:  15000000,
+		BaseFee:  big.NewInt(10 * params.GWei),
+	}
+	b, err := flashbots.NewBundle(txs, 15000002, 0, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := CheckFeeCaps(b, parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("CheckFeeCaps() len = %v, want %v: %v", len(issues), 2, issues)
+	}
+	if issues[0].Index != 1 || issues[0].Insufficient || issues[0].TxType != types.AccessListTxType {
+		t.Errorf("CheckFeeCaps()[0] = %v, want access list tx at risk", issues[0])
+	}
+	if issues[1].Index != 2 || !issues[1].Insufficient || issues[1].TxType != types.LegacyTxType {
+		t.Errorf("CheckFeeCaps()[1] = %v, want insufficient legacy tx", issues[1])
+	}
+}
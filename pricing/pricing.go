@@ -0,0 +1,146 @@
+// Package pricing computes what a bundle pays the block builder: per transaction and bundle effective gas prices, and
+// the coinbase transfer needed to reach a target effective gas price or profit share.
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/wphan/go-flashbots"
+)
+
+// BasisPoints is the denominator of profit shares, 10000 basis points is 100%
+const BasisPoints = 10000
+
+// TxPayment is what a single transaction pays the block builder
+type TxPayment struct {
+	TxHash            common.Hash
+	GasUsed           uint64
+	PriorityFee       *big.Int // PriorityFee is GasUsed * effective gas tip
+	CoinbaseTransfer  *big.Int // CoinbaseTransfer is the value sent directly to the coinbase
+	Payment           *big.Int // Payment is PriorityFee + CoinbaseTransfer
+	EffectiveGasPrice *big.Int // EffectiveGasPrice is Payment / GasUsed
+}
+
+// BundlePayment is what a bundle pays the block builder
+type BundlePayment struct {
+	Txs               []TxPayment
+	GasUsed           uint64
+	PriorityFee       *big.Int
+	CoinbaseTransfer  *big.Int
+	Payment           *big.Int // Payment is the builder payment, PriorityFee + CoinbaseTransfer
+	EffectiveGasPrice *big.Int // EffectiveGasPrice is the Flashbots effective bundle gas price, Payment / GasUsed
+}
+
+// FromSimulation computes the payment of b from a simulation result. Priority fees are computed from the
+// transactions and baseFee, so a simulation can be re-priced against a different base fee.
+// baseFee: base fee of the target block, nil for blocks before London
+func FromSimulation(b flashbots.Bundle, baseFee *big.Int, simulation flashbots.CallBundleResult) (p BundlePayment, retErr error) {
+	if len(simulation.Results) != len(b.Transactions) {
+		retErr = fmt.Errorf("simulation has %d results for %d transactions", len(simulation.Results), len(b.Transactions))
+		return
+	}
+
+	gasUsed := make([]uint64, len(b.Transactions))
+	coinbaseTransfers := make([]*big.Int, len(b.Transactions))
+	for i, result := range simulation.Results {
+		if result.TxHash != b.Transactions[i].Hash() {
+			retErr = fmt.Errorf("simulation result %d is for tx %s, want %s", i, result.TxHash.Hex(), b.Transactions[i].Hash().Hex())
+			return
+		}
+		gasUsed[i] = result.GasUsed
+		coinbaseTransfers[i] = result.EthSentToCoinbase
+	}
+	return FromGasEstimates(b, baseFee, gasUsed, coinbaseTransfers)
+}
+
+// FromGasEstimates computes the payment of b from per transaction gas estimates.
+// baseFee:           base fee of the target block, nil for blocks before London
+// gasUsed:           gas used by each transaction of b
+// coinbaseTransfers: value each transaction of b sends directly to the coinbase, nil if none do
+func FromGasEstimates(b flashbots.Bundle, baseFee *big.Int, gasUsed []uint64, coinbaseTransfers []*big.Int) (p BundlePayment, retErr error) {
+	if len(gasUsed) != len(b.Transactions) {
+		retErr = errors.New("must provide gas used for every transaction")
+		return
+	}
+	if coinbaseTransfers != nil && len(coinbaseTransfers) != len(b.Transactions) {
+		retErr = errors.New("must provide coinbase transfers for every transaction")
+		return
+	}
+
+	p.Txs = make([]TxPayment, len(b.Transactions))
+	p.PriorityFee = new(big.Int)
+	p.CoinbaseTransfer = new(big.Int)
+	for i, tx := range b.Transactions {
+		var coinbaseTransfer *big.Int
+		if coinbaseTransfers != nil {
+			coinbaseTransfer = coinbaseTransfers[i]
+		}
+		txPayment, err := txPayment(tx, baseFee, gasUsed[i], coinbaseTransfer)
+		if err != nil {
+			retErr = fmt.Errorf("failed to price tx %d (%s): %w", i, tx.Hash().Hex(), err)
+			return
+		}
+		p.Txs[i] = txPayment
+		p.GasUsed += txPayment.GasUsed
+		p.PriorityFee.Add(p.PriorityFee, txPayment.PriorityFee)
+		p.CoinbaseTransfer.Add(p.CoinbaseTransfer, txPayment.CoinbaseTransfer)
+	}
+	p.Payment = new(big.Int).Add(p.PriorityFee, p.CoinbaseTransfer)
+	p.EffectiveGasPrice = divGas(p.Payment, p.GasUsed)
+	return
+}
+
+func txPayment(tx *types.Transaction, baseFee *big.Int, gasUsed uint64, coinbaseTransfer *big.Int) (p TxPayment, retErr error) {
+	tip, err := tx.EffectiveGasTip(baseFee)
+	if err != nil {
+		retErr = err
+		return
+	}
+
+	p.TxHash = tx.Hash()
+	p.GasUsed = gasUsed
+	p.PriorityFee = new(big.Int).Mul(tip, new(big.Int).SetUint64(gasUsed))
+	p.CoinbaseTransfer = new(big.Int)
+	if coinbaseTransfer != nil {
+		p.CoinbaseTransfer.Set(coinbaseTransfer)
+	}
+	p.Payment = new(big.Int).Add(p.PriorityFee, p.CoinbaseTransfer)
+	p.EffectiveGasPrice = divGas(p.Payment, gasUsed)
+	return
+}
+
+// TransferForEffectiveGasPrice returns the additional coinbase transfer needed for the bundle to reach target
+// effective gas price, 0 if it already does.
+// paymentGas: gas used by the transaction making the transfer if it is added to the bundle, 0 if the transfer is
+// made by a transaction already in the bundle. the payment transaction is assumed to pay no priority fee
+func (p BundlePayment) TransferForEffectiveGasPrice(target *big.Int, paymentGas uint64) *big.Int {
+	required := new(big.Int).Mul(target, new(big.Int).SetUint64(p.GasUsed+paymentGas))
+	return positiveDiff(required, p.Payment)
+}
+
+// TransferForProfitShare returns the additional coinbase transfer needed for the builder to receive shareBps basis
+// points of profit, 0 if it already does. profit is the bundle profit before paying the builder.
+func (p BundlePayment) TransferForProfitShare(profit *big.Int, shareBps uint64) *big.Int {
+	required := new(big.Int).Mul(profit, new(big.Int).SetUint64(shareBps))
+	required.Div(required, big.NewInt(BasisPoints))
+	return positiveDiff(required, p.Payment)
+}
+
+func positiveDiff(a, b *big.Int) *big.Int {
+	diff := new(big.Int).Sub(a, b)
+	if diff.Sign() < 0 {
+		return new(big.Int)
+	}
+	return diff
+}
+
+func divGas(payment *big.Int, gasUsed uint64) *big.Int {
+	if gasUsed == 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Div(payment, new(big.Int).SetUint64(gasUsed))
+}
@@ -0,0 +1,110 @@
+package pricing
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/wphan/go-flashbots"
+	"github.com/wphan/go-flashbots/account"
+)
+
+func testBundle(t *testing.T) flashbots.Bundle {
+	pkey, pubAddr, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	tx, _ := types.SignNewTx(pkey, signer, &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     0,
+		GasTipCap: big.NewInt(2 * params.GWei),
+		GasFeeCap: big.NewInt(30 * params.GWei),
+		Gas:       100000,
+		To:        &pubAddr,
+	})
+	legacyTx, _ := types.SignNewTx(pkey, signer, &types.LegacyTx{
+		Nonce:    1,
+		GasPrice: big.NewInt(11 * params.GWei),
+		Gas:      21000,
+		To:       &pubAddr,
+	})
+	b, err := flashbots.NewBundle([]*types.Transaction{tx, legacyTx}, 100, 0, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestFromGasEstimates(t *testing.T) {
+	b := testBundle(t)
+	baseFee := big.NewInt(10 * params.GWei)
+
+	p, err := FromGasEstimates(b, baseFee, []uint64{79000, 21000}, []*big.Int{nil, big.NewInt(params.Ether / 100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tx 0 tips 2 gwei, tx 1 tips 11 - 10 = 1 gwei and transfers 0.01 ether
+	wantPriorityFee := big.NewInt(79000*2*params.GWei + 21000*params.GWei)
+	if p.PriorityFee.Cmp(wantPriorityFee) != 0 {
+		t.Errorf("PriorityFee = %v, want %v", p.PriorityFee, wantPriorityFee)
+	}
+	wantPayment := new(big.Int).Add(wantPriorityFee, big.NewInt(params.Ether/100))
+	if p.Payment.Cmp(wantPayment) != 0 {
+		t.Errorf("Payment = %v, want %v", p.Payment, wantPayment)
+	}
+	wantGasPrice := new(big.Int).Div(wantPayment, big.NewInt(100000))
+	if p.EffectiveGasPrice.Cmp(wantGasPrice) != 0 {
+		t.Errorf("EffectiveGasPrice = %v, want %v", p.EffectiveGasPrice, wantGasPrice)
+	}
+	if p.Txs[0].EffectiveGasPrice.Cmp(big.NewInt(2*params.GWei)) != 0 {
+		t.Errorf("Txs[0].EffectiveGasPrice = %v, want %v", p.Txs[0].EffectiveGasPrice, 2*params.GWei)
+	}
+
+	// 200 gwei over 121000 gas
+	target := big.NewInt(200 * params.GWei)
+	wantTransfer := new(big.Int).Sub(new(big.Int).Mul(target, big.NewInt(121000)), wantPayment)
+	if got := p.TransferForEffectiveGasPrice(target, 21000); got.Cmp(wantTransfer) != 0 {
+		t.Errorf("TransferForEffectiveGasPrice() = %v, want %v", got, wantTransfer)
+	}
+	if got := p.TransferForEffectiveGasPrice(big.NewInt(1), 0); got.Sign() != 0 {
+		t.Errorf("TransferForEffectiveGasPrice() = %v, want 0", got)
+	}
+
+	// 90% of 1 ether
+	wantTransfer = new(big.Int).Sub(big.NewInt(params.Ether*9/10), wantPayment)
+	if got := p.TransferForProfitShare(big.NewInt(params.Ether), 9000); got.Cmp(wantTransfer) != 0 {
+		t.Errorf("TransferForProfitShare() = %v, want %v", got, wantTransfer)
+	}
+
+	// fee cap of tx 0 is below the base fee
+	_, err = FromGasEstimates(b, big.NewInt(31*params.GWei), []uint64{79000, 21000}, nil)
+	if err == nil {
+		t.Errorf("FromGasEstimates() error = nil, want fee cap error")
+	}
+}
+
+func TestFromSimulation(t *testing.T) {
+	b := testBundle(t)
+	simulation := flashbots.CallBundleResult{
+		Results: []flashbots.CallBundleTxResult{
+			{TxHash: b.Transactions[0].Hash(), GasUsed: 79000},
+			{TxHash: b.Transactions[1].Hash(), GasUsed: 21000, EthSentToCoinbase: big.NewInt(params.Ether / 100)},
+		},
+	}
+
+	p, err := FromSimulation(b, big.NewInt(10*params.GWei), simulation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GasUsed != 100000 {
+		t.Errorf("GasUsed = %v, want %v", p.GasUsed, 100000)
+	}
+	if p.CoinbaseTransfer.Cmp(big.NewInt(params.Ether/100)) != 0 {
+		t.Errorf("CoinbaseTransfer = %v, want %v", p.CoinbaseTransfer, params.Ether/100)
+	}
+
+	simulation.Results = simulation.Results[:1]
+	if _, err = FromSimulation(b, big.NewInt(10*params.GWei), simulation); err == nil {
+		t.Errorf("FromSimulation() error = nil, want mismatched results error")
+	}
+}
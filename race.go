@@ -0,0 +1,117 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTooFewAccepts is the error of a race that ended before enough relays accepted the bundle
+var ErrTooFewAccepts = errors.New("too few relays accepted the bundle")
+
+// RaceOptions configures BatchSendBundleRace
+type RaceOptions struct {
+	Accepts int // Accepts is the number of relays that must accept the bundle before returning, default 1
+
+	// OnRemaining is called with every response that arrives after BatchSendBundleRace returned, one at a time from
+	// a background goroutine, may be nil
+	OnRemaining func(name string, resp SendBundleResponse)
+}
+
+type raceResult struct {
+	name string
+	resp SendBundleResponse
+}
+
+// accepted is true if the relay received resp and answered without a JSON-RPC error. Dry runs are not accepted.
+func accepted(resp SendBundleResponse) bool {
+	return !resp.DryRun && !requestFailed(resp)
+}
+
+// acceptedCount returns the number of responses in resps that accepted the bundle
+func acceptedCount(resps map[string]SendBundleResponse) (n int) {
+	for _, resp := range resps {
+		if accepted(resp) {
+			n++
+		}
+	}
+	return
+}
+
+// BatchSendBundleRace sends a Bundle on all relays concurrently and returns as soon as opts.Accepts of them accepted
+// it, with the responses received so far. The remaining requests keep running with ctx and their responses are
+// passed to opts.OnRemaining, cancel ctx to abort them. If every relay answered before enough accepted, retErr
+// wraps ErrTooFewAccepts. AdaptiveOptions, circuit breakers and shadow mode apply as in BatchSendBundleContext.
+// Nested relays are not raced individually: the accepts of a nested relay only count once its whole Send returned,
+// and its responses are all returned or all passed to opts.OnRemaining.
+func (r *BatchRelayClient) BatchSendBundleRace(ctx context.Context, b Bundle, opts RaceOptions) (resps map[string]SendBundleResponse, retErr error) {
+	want := opts.Accepts
+	if want <= 0 {
+		want = 1
+	}
+
+	clients, skipped := r.plan()
+	nested := r.nested()
+	results := make(chan raceResult)
+	pending := len(clients)
+	for _, client := range clients {
+		go func(client *RelayClient) {
+			results <- raceResult{name: client.Name(), resp: r.sendBundleTo(ctx, client, b, time.Time{})}
+		}(client)
+	}
+	// the relay clients of a nested relay are only known once it answered, count the relays until then
+	pendingNested := len(nested)
+	nestedDone := make(chan map[string]SendBundleResponse)
+	for _, relay := range nested {
+		go func(relay Relay) {
+			nestedDone <- relay.Send(ctx, b)
+		}(relay)
+	}
+
+	resps = make(map[string]SendBundleResponse)
+	setSkipped(resps, skipped)
+	received := 0
+	for received < want && pending+pendingNested > 0 {
+		select {
+		case result := <-results:
+			pending--
+			resps[result.name] = result.resp
+			if accepted(result.resp) {
+				received++
+			}
+		case nestedResps := <-nestedDone:
+			pendingNested--
+			merge(resps, nestedResps)
+			received += acceptedCount(nestedResps)
+		}
+	}
+
+	if pending+pendingNested > 0 {
+		go deliverRemaining(results, pending, nestedDone, pendingNested, opts.OnRemaining)
+	}
+	if received < want {
+		retErr = fmt.Errorf("%w: %d of %d", ErrTooFewAccepts, received, want)
+	}
+	return
+}
+
+// deliverRemaining passes the responses still pending to onRemaining
+func deliverRemaining(results chan raceResult, pending int, nestedDone chan map[string]SendBundleResponse, pendingNested int, onRemaining func(name string, resp SendBundleResponse)) {
+	for pending+pendingNested > 0 {
+		select {
+		case result := <-results:
+			pending--
+			if onRemaining != nil {
+				onRemaining(result.name, result.resp)
+			}
+		case nestedResps := <-nestedDone:
+			pendingNested--
+			for name, resp := range nestedResps {
+				if onRemaining != nil {
+					onRemaining(name, resp)
+				}
+			}
+		}
+	}
+}
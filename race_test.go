@@ -0,0 +1,148 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestBatchRelayClient_BatchSendBundleRace(t *testing.T) {
+	ok := []byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`)
+	release := make(chan struct{})
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write(ok)
+	}))
+	defer fast.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.Copy(io.Discard, req.Body)
+		<-release
+		_, _ = w.Write(ok)
+	}))
+	defer slow.Close()
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"bundle too large"}}`))
+	}))
+	defer rejecting.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	fastClient, _ := NewRelayClient(pkey, "fast", fast.URL, "")
+	slowClient, _ := NewRelayClient(pkey, "slow", slow.URL, "")
+	rejectingClient, _ := NewRelayClient(pkey, "rejecting", rejecting.URL, "")
+	batch, _ := NewBatchRelayClientFromRelayClients(fastClient, slowClient, rejectingClient)
+
+	remaining := make(chan string, 3)
+	resps, err := batch.BatchSendBundleRace(context.Background(), Bundle{}, RaceOptions{
+		OnRemaining: func(name string, resp SendBundleResponse) {
+			if resp.Error != nil {
+				t.Errorf("remaining relay %s error = %v", name, resp.Error)
+			}
+			remaining <- name
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resps["fast"]; !ok {
+		t.Errorf("responses = %v, want the fast relay", resps)
+	}
+	if _, ok := resps["slow"]; ok {
+		t.Error("race waited for the slow relay")
+	}
+
+	close(release)
+	select {
+	case name := <-remaining:
+		if name != "slow" && name != "rejecting" {
+			t.Errorf("remaining relay = %s", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("remaining response was not delivered")
+	}
+
+	resps, err = batch.BatchSendBundleRace(context.Background(), Bundle{}, RaceOptions{Accepts: 3})
+	if !errors.Is(err, ErrTooFewAccepts) {
+		t.Errorf("race with a rejecting relay error = %v, want ErrTooFewAccepts", err)
+	}
+	if len(resps) != 3 {
+		t.Errorf("got %d responses, want all 3", len(resps))
+	}
+}
+
+func TestBatchRelayClient_BatchSendBundleRace_nested(t *testing.T) {
+	ok := []byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write(ok)
+	}))
+	defer fast.Close()
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.Copy(io.Discard, req.Body)
+		<-release
+		_, _ = w.Write(ok)
+	}))
+	defer slow.Close()
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	defer unblock()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	fastClient, _ := NewRelayClient(pkey, "fast", fast.URL, "")
+	slowClient, _ := NewRelayClient(pkey, "slow", slow.URL, "")
+	innerClient, _ := NewRelayClient(pkey, "inner", fast.URL, "")
+	mock := &recordingRelay{name: "mock"}
+	inner, _ := NewBatchRelay("inner", innerClient, mock)
+	batch, _ := NewBatchRelay("batch", fastClient, slowClient, inner)
+
+	resps, err := batch.BatchSendBundleRace(context.Background(), Bundle{}, RaceOptions{Accepts: 3})
+	if err != nil {
+		t.Fatalf("race for 3 accepts error = %v", err)
+	}
+	for _, name := range []string{"fast", "inner", "mock"} {
+		if resp, ok := resps[name]; !ok || !accepted(resp) {
+			t.Errorf("response of %s = %+v, want accepted", name, resp)
+		}
+	}
+	if _, ok := resps["slow"]; ok {
+		t.Error("race waited for the slow relay")
+	}
+
+	batch.SetShadow(&ShadowOptions{Live: []string{"slow"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	remaining := make(chan string, 1)
+	go func() {
+		resps, err = batch.BatchSendBundleRace(ctx, Bundle{}, RaceOptions{
+			OnRemaining: func(name string, resp SendBundleResponse) { remaining <- name },
+		})
+		cancel()
+	}()
+	select {
+	case <-ctx.Done():
+		t.Fatal("race returned before the live relay answered, with dry runs accepted")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unblock()
+	<-ctx.Done()
+	if err != nil {
+		t.Fatalf("race with shadowed relays error = %v", err)
+	}
+	for _, name := range []string{"fast", "inner", "mock"} {
+		if !resps[name].DryRun {
+			t.Errorf("response of shadowed %s = %+v, want a dry run", name, resps[name])
+		}
+	}
+	if !accepted(resps["slow"]) {
+		t.Errorf("response of the live relay = %+v, want accepted", resps["slow"])
+	}
+	select {
+	case name := <-remaining:
+		t.Errorf("remaining response of %s, want all relays answered", name)
+	default:
+	}
+}
@@ -0,0 +1,271 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is the error of requests rejected by a RelayClient rate limit
+var ErrRateLimited = errors.New("request rejected by rate limit")
+
+type LimitMode int
+
+const (
+	LimitQueue    LimitMode = iota // LimitQueue makes requests wait for a token until their context is done
+	LimitFailFast                  // LimitFailFast rejects requests with ErrRateLimited when there is no token
+	LimitDropLow                   // LimitDropLow queues requests and, when the queue is full, drops the lowest priority one
+)
+
+func (m LimitMode) String() string {
+	switch m {
+	case LimitQueue:
+		return "queue"
+	case LimitFailFast:
+		return "failFast"
+	case LimitDropLow:
+		return "dropLow"
+	default:
+		return "unknown"
+	}
+}
+
+// RateLimit is a token bucket holding up to Burst tokens, refilled at Rate tokens per second. Burst must be at least 1
+// and Rate positive. Every request takes a token. Queued requests get tokens by priority, then in arrival order.
+type RateLimit struct {
+	Rate     float64
+	Burst    int
+	Mode     LimitMode
+	MaxQueue int // MaxQueue is the maximum number of requests waiting for a token, 0 for no limit
+}
+
+// RateLimitBudget is a snapshot of a rate limit of a RelayClient
+type RateLimitBudget struct {
+	Method string  // Method is the JSON-RPC method limited, empty for the limit of all methods
+	Tokens float64 // Tokens is the number of requests that can be sent right now
+	Queued int     // Queued is the number of requests waiting for a token
+	Limit  RateLimit
+}
+
+type priorityKey struct{}
+
+// WithPriority sets the priority of the relay requests made with ctx. Rate limits in LimitDropLow mode drop lower
+// priority requests first. The default priority is 0.
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+func priorityOf(ctx context.Context) int {
+	priority, _ := ctx.Value(priorityKey{}).(int)
+	return priority
+}
+
+type limitWaiter struct {
+	priority int
+	granted  chan error
+}
+
+type rateLimiter struct {
+	limit RateLimit
+
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+	waiters []*limitWaiter
+	timer   *time.Timer
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		tokens: float64(limit.Burst),
+		last:   time.Now(),
+	}
+}
+
+// refill adds the tokens accrued since the last refill. l.mu must be held.
+func (l *rateLimiter) refill() {
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.limit.Rate
+	if l.tokens > float64(l.limit.Burst) {
+		l.tokens = float64(l.limit.Burst)
+	}
+	l.last = now
+}
+
+// acquire takes a token, waiting for one unless the limit is in LimitFailFast mode. Requests that are rejected,
+// dropped or whose ctx is done before they get a token fail with ErrRateLimited.
+func (l *rateLimiter) acquire(ctx context.Context) (retErr error) {
+	l.mu.Lock()
+	l.refill()
+	if len(l.waiters) == 0 && l.tokens >= 1 {
+		l.tokens--
+		l.mu.Unlock()
+		return
+	}
+	if l.limit.Mode == LimitFailFast {
+		l.mu.Unlock()
+		return ErrRateLimited
+	}
+
+	w := &limitWaiter{
+		priority: priorityOf(ctx),
+		granted:  make(chan error, 1),
+	}
+	if l.limit.MaxQueue > 0 && len(l.waiters) >= l.limit.MaxQueue {
+		lowest := l.lowest()
+		if l.limit.Mode != LimitDropLow || l.waiters[lowest].priority >= w.priority {
+			l.mu.Unlock()
+			return ErrRateLimited
+		}
+		l.waiters[lowest].granted <- ErrRateLimited
+		l.waiters = append(l.waiters[:lowest], l.waiters[lowest+1:]...)
+	}
+	l.waiters = append(l.waiters, w)
+	l.schedule()
+	l.mu.Unlock()
+
+	select {
+	case retErr = <-w.granted:
+		return
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	retErr = fmt.Errorf("%w: %v while waiting for a token", ErrRateLimited, ctx.Err())
+	for i, waiter := range l.waiters {
+		if waiter == w {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			return
+		}
+	}
+	// the token was granted while ctx was done, give it back
+	if err := <-w.granted; err == nil {
+		l.tokens++
+		l.schedule()
+	}
+	return
+}
+
+// lowest returns the index of the most recent waiter with the lowest priority. l.mu must be held.
+func (l *rateLimiter) lowest() (idx int) {
+	for i, w := range l.waiters {
+		if w.priority <= l.waiters[idx].priority {
+			idx = i
+		}
+	}
+	return
+}
+
+// schedule arms a timer for when the next token is available if requests are waiting. l.mu must be held.
+func (l *rateLimiter) schedule() {
+	if l.timer != nil || len(l.waiters) == 0 {
+		return
+	}
+	wait := time.Duration((1 - l.tokens) / l.limit.Rate * float64(time.Second))
+	if wait < 0 {
+		wait = 0
+	}
+	l.timer = time.AfterFunc(wait, l.dispatch)
+}
+
+// dispatch hands out the available tokens to the highest priority waiters
+func (l *rateLimiter) dispatch() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timer = nil
+	l.refill()
+	for l.tokens >= 1 && len(l.waiters) > 0 {
+		highest := 0
+		for i, w := range l.waiters {
+			if w.priority > l.waiters[highest].priority {
+				highest = i
+			}
+		}
+		l.tokens--
+		l.waiters[highest].granted <- nil
+		l.waiters = append(l.waiters[:highest], l.waiters[highest+1:]...)
+	}
+	l.schedule()
+}
+
+// release returns a token taken by acquire that was not used, handing it to a waiter if there is one
+func (l *rateLimiter) release() {
+	l.mu.Lock()
+	l.refill()
+	l.tokens++
+	if l.tokens > float64(l.limit.Burst) {
+		l.tokens = float64(l.limit.Burst)
+	}
+	if l.timer == nil || !l.timer.Stop() {
+		l.mu.Unlock()
+		return
+	}
+	l.timer = nil
+	l.mu.Unlock()
+	l.dispatch()
+}
+
+func (l *rateLimiter) budget(method string) RateLimitBudget {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	return RateLimitBudget{
+		Method: method,
+		Tokens: l.tokens,
+		Queued: len(l.waiters),
+		Limit:  l.limit,
+	}
+}
+
+// SetRateLimit limits the requests r sends with the JSON-RPC method, e.g. "eth_sendBundle", or all its requests if
+// method is empty. A request must get a token from both its method's limit and the limit of all methods. nil
+// removes the limit. Warm-up requests are not limited. SetRateLimit must not be called concurrently with requests.
+func (r *RelayClient) SetRateLimit(method string, limit *RateLimit) error {
+	if limit == nil {
+		delete(r.rateLimits, method)
+		return nil
+	}
+	if limit.Burst < 1 {
+		return fmt.Errorf("rate limit burst must be at least 1, got %d", limit.Burst)
+	}
+	if !(limit.Rate > 0) {
+		return fmt.Errorf("rate limit rate must be positive, got %v", limit.Rate)
+	}
+	if r.rateLimits == nil {
+		r.rateLimits = make(map[string]*rateLimiter)
+	}
+	r.rateLimits[method] = newRateLimiter(*limit)
+	return nil
+}
+
+// RateLimitBudgets returns the current budget of every rate limit of r by method, "" for the limit of all methods
+func (r RelayClient) RateLimitBudgets() (budgets map[string]RateLimitBudget) {
+	budgets = make(map[string]RateLimitBudget, len(r.rateLimits))
+	for method, l := range r.rateLimits {
+		budgets[method] = l.budget(method)
+	}
+	return
+}
+
+// waitRateLimit takes a token for a request with method from its method's limit and the limit of all methods
+func (r *RelayClient) waitRateLimit(ctx context.Context, method string) error {
+	methodLimit, ok := r.rateLimits[method]
+	if ok {
+		if err := methodLimit.acquire(ctx); err != nil {
+			return err
+		}
+	}
+	if l, ok := r.rateLimits[""]; ok {
+		if err := l.acquire(ctx); err != nil {
+			if methodLimit != nil {
+				methodLimit.release()
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+func Test_rateLimiter_queue(t *testing.T) {
+	l := newRateLimiter(RateLimit{Rate: 50, Burst: 1, Mode: LimitQueue})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("3 acquires at 50/s with burst 1 took %v, want at least 40ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(ctx); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("acquire() with expiring context error = %v, want ErrRateLimited", err)
+	}
+	if budget := l.budget("eth_sendBundle"); budget.Queued != 0 {
+		t.Errorf("budget() = %+v, want no queued request", budget)
+	}
+}
+
+func Test_rateLimiter_dropLow(t *testing.T) {
+	l := newRateLimiter(RateLimit{Rate: 20, Burst: 1, Mode: LimitDropLow, MaxQueue: 1})
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	low := make(chan error, 1)
+	go func() { low <- l.acquire(context.Background()) }()
+	time.Sleep(5 * time.Millisecond)
+
+	high := make(chan error, 1)
+	go func() { high <- l.acquire(WithPriority(context.Background(), 1)) }()
+	if err := <-low; !errors.Is(err, ErrRateLimited) {
+		t.Errorf("low priority acquire() error = %v, want ErrRateLimited", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if err := l.acquire(context.Background()); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("acquire() on a full queue error = %v, want ErrRateLimited", err)
+	}
+	if err := <-high; err != nil {
+		t.Errorf("high priority acquire() error = %v", err)
+	}
+}
+
+func TestRelayClient_waitRateLimit_cancelled(t *testing.T) {
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	r, _ := NewRelayClient(pkey, "relay", "http://127.0.0.1:0", "")
+	r.SetRateLimit("eth_sendBundle", &RateLimit{Rate: 0.001, Burst: 1, Mode: LimitFailFast})
+	r.SetRateLimit("", &RateLimit{Rate: 0.001, Burst: 1, Mode: LimitQueue})
+	if err := r.waitRateLimit(context.Background(), "eth_cancelBundle"); err != nil {
+		t.Fatalf("waitRateLimit() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := r.waitRateLimit(ctx, "eth_sendBundle"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("waitRateLimit() with a cancelled wait for all methods error = %v, want ErrRateLimited", err)
+	}
+	if b := r.RateLimitBudgets()["eth_sendBundle"]; b.Tokens < 1 {
+		t.Errorf("eth_sendBundle budget = %+v, want the token back after the cancelled wait", b)
+	}
+}
+
+func TestRelayClient_SetRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	r, _ := NewRelayClient(pkey, "relay", server.URL, "")
+	r.SetRateLimit("eth_sendBundle", &RateLimit{Rate: 0.001, Burst: 1, Mode: LimitFailFast})
+	r.SetRateLimit("", &RateLimit{Rate: 0.001, Burst: 3, Mode: LimitFailFast})
+
+	if resp := r.SendBundle(Bundle{}); resp.Error != nil {
+		t.Fatalf("SendBundle() error = %v", resp.Error)
+	}
+	if resp := r.SendBundle(Bundle{}); !errors.Is(resp.Error, ErrRateLimited) {
+		t.Errorf("SendBundle() over the method limit error = %v, want ErrRateLimited", resp.Error)
+	}
+	if resp := r.CancelBundle("uuid"); resp.Error != nil {
+		t.Errorf("CancelBundle() error = %v, want only the limit of all methods to apply", resp.Error)
+	}
+
+	for _, limit := range []RateLimit{{Rate: 1, Burst: 0}, {Rate: 0, Burst: 1}, {Rate: -1, Burst: 1}} {
+		if err := r.SetRateLimit("eth_callBundle", &limit); err == nil {
+			t.Errorf("SetRateLimit(%+v) error = nil", limit)
+		}
+	}
+
+	budgets := r.RateLimitBudgets()
+	if b := budgets["eth_sendBundle"]; b.Tokens >= 1 || b.Limit.Burst != 1 {
+		t.Errorf("eth_sendBundle budget = %+v, want no token left", b)
+	}
+	if b := budgets[""]; b.Tokens < 1 || b.Tokens >= 2 {
+		t.Errorf("budget of all methods = %+v, want 1 token left", b)
+	}
+
+	batch, _ := NewBatchRelayClientFromRelayClients(r)
+	batch.SetCircuitBreaker(&BreakerOptions{FailureThreshold: 1, Cooldown: time.Minute})
+	if resp := batch.BatchSendBundle(Bundle{})["relay"]; !errors.Is(resp.Error, ErrRateLimited) {
+		t.Errorf("BatchSendBundle() error = %v, want ErrRateLimited", resp.Error)
+	}
+	if state := batch.BreakerState("relay"); state != BreakerClosed {
+		t.Errorf("BreakerState() = %v, rate limited requests must not open the breaker", state)
+	}
+	if s := batch.Stats()["relay"]; s.Requests != 0 {
+		t.Errorf("Stats() = %+v, rate limited requests must not be recorded", s)
+	}
+}
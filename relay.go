@@ -0,0 +1,183 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Relay sends, simulates and cancels bundles on one or more relays. RelayClient and BatchRelayClient are relays, and
+// a BatchRelayClient may contain other relays, including other batches. Responses and statistics are keyed by the
+// name of the RelayClient that served them, which must be unique within a batch.
+type Relay interface {
+	// Name is the name of the relay
+	Name() string
+	// Send sends b, returning the response of every relay client by name
+	Send(ctx context.Context, b Bundle) map[string]SendBundleResponse
+	// Simulate simulates b with eth_callBundle
+	Simulate(ctx context.Context, b Bundle, opts *SimulationOptions) SendBundleResponse
+	// Cancel cancels the bundles sent with replacementUUID, returning the response of every relay client by name
+	Cancel(ctx context.Context, replacementUUID string) map[string]SendBundleResponse
+	// Stats returns the eth_sendBundle statistics of every relay client by name
+	Stats() map[string]RelayStats
+}
+
+var (
+	_ Relay = (*RelayClient)(nil)
+	_ Relay = (*BatchRelayClient)(nil)
+)
+
+// clientStats holds the eth_sendBundle statistics of a RelayClient
+type clientStats struct {
+	mu    sync.Mutex
+	stats relayStats
+}
+
+func (s *clientStats) record(resp SendBundleResponse) {
+	if !sent(resp) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.add(resp.Duration, requestFailed(resp), time.Now())
+}
+
+// Send sends b on r, see Relay
+func (r *RelayClient) Send(ctx context.Context, b Bundle) map[string]SendBundleResponse {
+	return map[string]SendBundleResponse{
+		r.name: r.sendBundle(ctx, b),
+	}
+}
+
+// Simulate simulates b on r, see SimulateBundleContext
+func (r *RelayClient) Simulate(ctx context.Context, b Bundle, opts *SimulationOptions) SendBundleResponse {
+	return r.simulateBundle(ctx, b, opts)
+}
+
+// Cancel cancels the bundles sent on r with replacementUUID, see Relay
+func (r *RelayClient) Cancel(ctx context.Context, replacementUUID string) map[string]SendBundleResponse {
+	return map[string]SendBundleResponse{
+		r.name: r.cancelBundle(ctx, replacementUUID),
+	}
+}
+
+// Stats returns the statistics of the bundles r sent
+func (r *RelayClient) Stats() map[string]RelayStats {
+	r.stats.mu.Lock()
+	defer r.stats.mu.Unlock()
+	return map[string]RelayStats{
+		r.name: r.stats.stats.snapshot(r.name),
+	}
+}
+
+// NewBatchRelay creates a BatchRelayClient called name from relays. Relay clients are batched as with
+// NewBatchRelayClientFromRelayClients, other relays, e.g. other batches, are nested: bundles are sent and canceled
+// on them too, but they keep their own statistics, adaptive options and circuit breakers.
+func NewBatchRelay(name string, relays ...Relay) (b *BatchRelayClient, retErr error) {
+	b = &BatchRelayClient{
+		name: name,
+	}
+	for _, relay := range relays {
+		switch relay := relay.(type) {
+		case nil:
+			b, retErr = nil, errors.New("must not provide a nil relay")
+			return
+		case *RelayClient:
+			if relay == nil {
+				b, retErr = nil, errors.New("must not provide a nil relay")
+				return
+			}
+			b.relayClients = append(b.relayClients, relay)
+		default:
+			b.relays = append(b.relays, relay)
+		}
+	}
+	return
+}
+
+// Name returns the name of r, "batch" if it was created without one
+func (r *BatchRelayClient) Name() string {
+	if r.name == "" {
+		return "batch"
+	}
+	return r.name
+}
+
+// Send sends b on all relays concurrently, see BatchSendBundleContext
+func (r *BatchRelayClient) Send(ctx context.Context, b Bundle) map[string]SendBundleResponse {
+	return r.BatchSendBundleContext(ctx, b)
+}
+
+// Simulate simulates b on the first relay client that can simulate. If there is none the nested relays are tried
+// in order until one answers without an error, the response of the last one is returned if none did.
+func (r *BatchRelayClient) Simulate(ctx context.Context, b Bundle, opts *SimulationOptions) (resp SendBundleResponse) {
+	for _, client := range r.relayClients {
+		if client.CanSimulate() {
+			return client.simulateBundle(ctx, b, opts)
+		}
+	}
+	resp = SendBundleResponse{
+		Error: errors.New("no relay client with a simulation endpoint or backend"),
+	}
+	for _, relay := range r.relays {
+		if resp = relay.Simulate(ctx, b, opts); resp.Error == nil {
+			return
+		}
+	}
+	return
+}
+
+// Cancel cancels the bundles sent with replacementUUID on all relays concurrently
+func (r *BatchRelayClient) Cancel(ctx context.Context, replacementUUID string) (resps map[string]SendBundleResponse) {
+	resps = make(map[string]SendBundleResponse)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, client := range r.relayClients {
+		wg.Add(1)
+		go func(client *RelayClient) {
+			defer wg.Done()
+			resp := r.cancelBundleOn(ctx, client, replacementUUID)
+			mu.Lock()
+			resps[client.Name()] = resp
+			mu.Unlock()
+		}(client)
+	}
+	for _, relay := range r.nested() {
+		wg.Add(1)
+		go func(relay Relay) {
+			defer wg.Done()
+			nested := relay.Cancel(ctx, replacementUUID)
+			mu.Lock()
+			merge(resps, nested)
+			mu.Unlock()
+		}(relay)
+	}
+	wg.Wait()
+
+	return
+}
+
+// sendNested sends b on the nested relays of r concurrently, adding their responses to resps
+func (r *BatchRelayClient) sendNested(ctx context.Context, b Bundle, resps map[string]SendBundleResponse) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, relay := range r.nested() {
+		wg.Add(1)
+		go func(relay Relay) {
+			defer wg.Done()
+			nested := relay.Send(ctx, b)
+			mu.Lock()
+			merge(resps, nested)
+			mu.Unlock()
+		}(relay)
+	}
+	wg.Wait()
+}
+
+func merge(resps, nested map[string]SendBundleResponse) {
+	for name, resp := range nested {
+		resps[name] = resp
+	}
+}
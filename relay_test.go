@@ -0,0 +1,160 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+// recordingRelay is a Relay that records the bundles and cancellations it receives
+type recordingRelay struct {
+	name    string
+	barrier *sync.WaitGroup // barrier makes Send wait until every relay sharing it was called, may be nil
+
+	mu         sync.Mutex
+	sent       []Bundle
+	canceled   []string
+	statsCalls int
+}
+
+func (r *recordingRelay) Name() string { return r.name }
+
+func (r *recordingRelay) Send(ctx context.Context, b Bundle) map[string]SendBundleResponse {
+	if r.barrier != nil {
+		r.barrier.Done()
+		r.barrier.Wait()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, b)
+	return map[string]SendBundleResponse{r.name: {ResponseBytes: []byte(`{"result":{}}`)}}
+}
+
+func (r *recordingRelay) Simulate(ctx context.Context, b Bundle, opts *SimulationOptions) SendBundleResponse {
+	return SendBundleResponse{Error: errors.New("not supported")}
+}
+
+func (r *recordingRelay) Cancel(ctx context.Context, replacementUUID string) map[string]SendBundleResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.canceled = append(r.canceled, replacementUUID)
+	return map[string]SendBundleResponse{r.name: {}}
+}
+
+func (r *recordingRelay) Stats() map[string]RelayStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statsCalls++
+	return map[string]RelayStats{r.name: {Name: r.name}}
+}
+
+func TestNewBatchRelay_nested(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	a, _ := NewRelayClient(pkey, "a", server.URL, "")
+	b, _ := NewRelayClient(pkey, "b", server.URL, "")
+	mock := &recordingRelay{name: "mock"}
+
+	inner, err := NewBatchRelay("inner", b, mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer, err := NewBatchRelay("outer", a, inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBatchRelay("nil", (*RelayClient)(nil)); err == nil {
+		t.Error("NewBatchRelay() with a nil relay client succeeded")
+	}
+	if name := (&BatchRelayClient{}).Name(); name != "batch" {
+		t.Errorf("Name() of unnamed batch = %q, want batch", name)
+	}
+
+	for _, send := range []func() map[string]SendBundleResponse{
+		func() map[string]SendBundleResponse { return outer.Send(context.Background(), Bundle{}) },
+		func() map[string]SendBundleResponse { return outer.BatchSendBundle(Bundle{}) },
+	} {
+		resps := send()
+		if len(resps) != 3 {
+			t.Fatalf("got %d responses, want 3: %v", len(resps), resps)
+		}
+		for name, resp := range resps {
+			if resp.Error != nil {
+				t.Errorf("relay %s error = %v", name, resp.Error)
+			}
+		}
+	}
+	if len(mock.sent) != 2 {
+		t.Errorf("nested relay got %d bundles, want 2", len(mock.sent))
+	}
+
+	stats := outer.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("got stats of %d relays, want 3: %v", len(stats), stats)
+	}
+	if s := stats["b"]; s.Requests != 2 {
+		t.Errorf("nested relay client stats = %+v, want 2 requests", s)
+	}
+	if s := b.Stats()["b"]; s.Requests != 2 || s.Errors != 0 {
+		t.Errorf("relay client stats = %+v, want 2 requests", s)
+	}
+
+	resps := outer.Cancel(context.Background(), "uuid")
+	if len(resps) != 3 || len(mock.canceled) != 1 || mock.canceled[0] != "uuid" {
+		t.Errorf("Cancel() = %v, nested relay canceled %v", resps, mock.canceled)
+	}
+
+	if resp := outer.Simulate(context.Background(), Bundle{}, nil); resp.Error == nil {
+		t.Error("Simulate() without a simulation endpoint succeeded")
+	}
+}
+
+func TestBatchRelayClient_sendNested(t *testing.T) {
+	var barrier sync.WaitGroup
+	barrier.Add(2)
+	first := &recordingRelay{name: "first", barrier: &barrier}
+	second := &recordingRelay{name: "second", barrier: &barrier}
+	batch, _ := NewBatchRelay("batch", first, second)
+	batch.SetAdaptive(&AdaptiveOptions{MaxErrorRate: 0.5, Order: true})
+
+	done := make(chan map[string]SendBundleResponse)
+	go func() { done <- batch.BatchSendBundle(Bundle{}) }()
+	select {
+	case resps := <-done:
+		if len(resps) != 2 {
+			t.Errorf("got %d responses, want 2: %v", len(resps), resps)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nested relays were not sent to concurrently")
+	}
+	if first.statsCalls != 0 || second.statsCalls != 0 {
+		t.Errorf("sending read the stats of nested relays %d times", first.statsCalls+second.statsCalls)
+	}
+}
+
+func TestBatchRelayClient_Simulate_nested(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65","results":[],"totalGasUsed":21000}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	simulating, _ := NewRelayClient(pkey, "simulating", server.URL, server.URL)
+	inner, _ := NewBatchRelay("inner", simulating)
+	outer, _ := NewBatchRelay("outer", &recordingRelay{name: "mock"}, inner)
+
+	resp := outer.Simulate(context.Background(), Bundle{}, nil)
+	if resp.Error != nil || resp.Endpoint != server.URL {
+		t.Errorf("Simulate() = %+v, want simulated by the second nested relay", resp)
+	}
+}
@@ -0,0 +1,228 @@
+// Package replay records relay traffic to fixture files and serves it back, so RelayClient can be tested offline
+// against captured relay responses. Requests are matched by JSON-RPC method and params; the X-Flashbots-Signature
+// header, the request id and the endpoint are ignored.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Interaction is a recorded relay request and its response, one per line in a fixture file
+type Interaction struct {
+	Method      string          `json:"method"`
+	Params      json.RawMessage `json:"params"`
+	Status      int             `json:"status"`
+	ContentType string          `json:"contentType,omitempty"`
+	Response    string          `json:"response"`
+}
+
+// rpcRequest is the part of a JSON-RPC request interactions are matched by
+type rpcRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// matchKey returns the key of a request with method and params. params are re-encoded so that formatting and
+// object key order do not matter.
+func matchKey(method string, params json.RawMessage) (string, error) {
+	var v interface{}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &v); err != nil {
+			return "", fmt.Errorf("failed to unmarshal params: %w", err)
+		}
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return method + " " + string(canonical), nil
+}
+
+// readRequest reads the JSON-RPC method and params of req, leaving its body readable
+func readRequest(req *http.Request) (rpc rpcRequest, retErr error) {
+	if req.Body == nil {
+		retErr = errors.New("request has no body")
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		retErr = fmt.Errorf("failed to read request body: %w", err)
+		return
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err := json.Unmarshal(body, &rpc); err != nil {
+		retErr = fmt.Errorf("failed to unmarshal request: %w", err)
+		return
+	}
+	return
+}
+
+// Recorder is an http.RoundTripper that sends requests with its next RoundTripper and appends every request and
+// response to a fixture file
+type Recorder struct {
+	next http.RoundTripper
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecorder creates a recorder appending to the fixture file at path, creating it if needed. next sends the
+// requests, http.DefaultTransport if nil.
+func NewRecorder(path string, next http.RoundTripper) (r *Recorder, retErr error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		retErr = fmt.Errorf("failed to open fixture: %w", err)
+		return
+	}
+	r = &Recorder{
+		next: next,
+		file: file,
+	}
+	return
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	rpc, err := readRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	line, err := json.Marshal(Interaction{
+		Method:      rpc.Method,
+		Params:      rpc.Params,
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Response:    string(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal interaction: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return resp, nil
+}
+
+// Close closes the fixture file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// Replayer is an http.RoundTripper that answers requests with the responses recorded in a fixture file. Identical
+// requests get the recorded responses in order, the last one is repeated once they run out. Requests that were
+// not recorded fail.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+	served       map[string]int
+}
+
+// NewReplayer creates a replayer serving the fixture file at path
+func NewReplayer(path string) (r *Replayer, retErr error) {
+	file, err := os.Open(path)
+	if err != nil {
+		retErr = fmt.Errorf("failed to open fixture: %w", err)
+		return
+	}
+	defer file.Close()
+	return NewReplayerFromReader(file)
+}
+
+// NewReplayerFromReader creates a replayer serving the fixture lines read from fixture
+func NewReplayerFromReader(fixture io.Reader) (r *Replayer, retErr error) {
+	r = &Replayer{
+		interactions: make(map[string][]Interaction),
+		served:       make(map[string]int),
+	}
+
+	scanner := bufio.NewScanner(fixture)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fixture line %d: %w", line, err)
+		}
+		key, err := matchKey(interaction.Method, interaction.Params)
+		if err != nil {
+			return nil, fmt.Errorf("fixture line %d: %w", line, err)
+		}
+		r.interactions[key] = append(r.interactions[key], interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	return
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	rpc, err := readRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	key, err := matchKey(rpc.Method, rpc.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	recorded := r.interactions[key]
+	if len(recorded) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s", rpc.Method)
+	}
+	idx := r.served[key]
+	if idx >= len(recorded) {
+		idx = len(recorded) - 1
+	}
+	r.served[key]++
+	interaction := recorded[idx]
+	r.mu.Unlock()
+
+	header := make(http.Header)
+	if interaction.ContentType != "" {
+		header.Set("Content-Type", interaction.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(interaction.Response)),
+		ContentLength: int64(len(interaction.Response)),
+		Request:       req,
+	}, nil
+}
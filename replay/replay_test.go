@@ -0,0 +1,80 @@
+package replay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/wphan/go-flashbots"
+	"github.com/wphan/go-flashbots/account"
+)
+
+func Test_matchKey(t *testing.T) {
+	a, _ := matchKey("eth_callBundle", []byte(`[{"txs":["0x01"],"blockNumber":"0x11"}]`))
+	b, _ := matchKey("eth_callBundle", []byte(`[ { "blockNumber": "0x11", "txs": [ "0x01" ] } ]`))
+	c, _ := matchKey("eth_sendBundle", []byte(`[{"txs":["0x01"],"blockNumber":"0x11"}]`))
+	if a != b {
+		t.Errorf("matchKey() differs by formatting: %v, %v", a, b)
+	}
+	if a == c {
+		t.Errorf("matchKey() does not differ by method: %v", a)
+	}
+}
+
+func TestRecorder_Replayer(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if calls == 1 {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"results":[],"totalGasUsed":42000}}`))
+	}))
+
+	fixture := filepath.Join(t.TempDir(), "relay.jsonl")
+	recorder, err := NewRecorder(fixture, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	b := flashbots.Bundle{TxsByteString: []string{"0x01"}, BlockNumber: "0x11", StateBlockNumber: "0x10"}
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	live, _ := flashbots.NewRelayClient(pkey, "relay", server.URL, server.URL)
+	live.SetHTTPClient(&http.Client{Transport: recorder})
+	sent := live.SendBundle(b)
+	simulated := live.SimulateBundleContext(context.Background(), b, nil)
+	if sent.Error != nil || simulated.Error != nil {
+		t.Fatalf("live requests failed: %v, %v", sent.Error, simulated.Error)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	server.Close()
+
+	replayer, err := NewReplayer(fixture)
+	if err != nil {
+		t.Fatalf("NewReplayer() error = %v", err)
+	}
+	// a different signing key and endpoint must still match the recording
+	otherKey, _, _ := account.LoadPrivateKeyString("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	offline, _ := flashbots.NewRelayClient(otherKey, "relay", "http://relay.invalid", "http://relay.invalid")
+	offline.SetHTTPClient(&http.Client{Transport: replayer})
+
+	for i := 0; i < 2; i++ {
+		resp := offline.SendBundle(b)
+		if resp.Error != nil || string(resp.ResponseBytes) != string(sent.ResponseBytes) {
+			t.Errorf("replayed SendBundle() = %s, %v, want %s", resp.ResponseBytes, resp.Error, sent.ResponseBytes)
+		}
+	}
+	gasUsed, err := flashbots.ParseGasUsed(offline.SimulateBundleContext(context.Background(), b, nil).ResponseBytes)
+	if err != nil || gasUsed != 42000 {
+		t.Errorf("replayed simulation gas used = %v, %v, want 42000", gasUsed, err)
+	}
+	if resp := offline.CancelBundle("uuid"); resp.Error == nil {
+		t.Errorf("CancelBundle() without recording error = nil")
+	}
+}
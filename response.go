@@ -0,0 +1,104 @@
+package flashbots
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// rpcResponse is the envelope of a JSON-RPC response
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// isRPCResponse is true if bytes is a JSON-RPC response with a result or an error
+func isRPCResponse(bytes []byte) bool {
+	var resp rpcResponse
+	if err := json.Unmarshal(bytes, &resp); err != nil {
+		return false
+	}
+	return resp.Error != nil || (len(resp.Result) > 0 && string(resp.Result) != "null")
+}
+
+// decodeRPCResponse unmarshals the result of a JSON-RPC response into result. A JSON-RPC error in the response is
+// returned as an *RPCError, a missing or null result as an error.
+func decodeRPCResponse(bytes []byte, result interface{}) (retErr error) {
+	var resp rpcResponse
+	err := json.Unmarshal(bytes, &resp)
+	if err != nil {
+		retErr = fmt.Errorf("failed to unmarshal response: %s\nerror: %w", string(bytes), err)
+		return
+	}
+	if resp.Error != nil {
+		retErr = resp.Error
+		return
+	}
+	if len(resp.Result) == 0 || string(resp.Result) == "null" {
+		retErr = errors.New("no result in response: " + string(bytes))
+		return
+	}
+
+	err = json.Unmarshal(resp.Result, result)
+	if err != nil {
+		retErr = fmt.Errorf("failed to unmarshal result: %s\nerror: %w", string(resp.Result), err)
+		return
+	}
+	return
+}
+
+// SendBundleResult is the result of an eth_sendBundle request
+type SendBundleResult struct {
+	BundleHash common.Hash `json:"bundleHash"`
+}
+
+// ParseSendBundleResponse decodes the response bytes of an eth_sendBundle request. A JSON-RPC error in the response
+// is returned as an *RPCError.
+func ParseSendBundleResponse(bytes []byte) (result SendBundleResult, retErr error) {
+	retErr = decodeRPCResponse(bytes, &result)
+	return
+}
+
+// requireResultField returns an error if the result object of a JSON-RPC response has no field called name
+func requireResultField(bytes []byte, name string) error {
+	var fields map[string]json.RawMessage
+	if err := decodeRPCResponse(bytes, &fields); err != nil {
+		return err
+	}
+	if value, ok := fields[name]; !ok || string(value) == "null" {
+		return fmt.Errorf("no %s in result: %s", name, string(bytes))
+	}
+	return nil
+}
+
+// ParseGasUsed returns the total gas used from the response bytes of an eth_callBundle request, an error if the
+// result has no totalGasUsed
+func ParseGasUsed(bytes []byte) (gasUsed uint64, retErr error) {
+	result, err := ParseCallBundleResponse(bytes)
+	if err != nil {
+		retErr = err
+		return
+	}
+	if retErr = requireResultField(bytes, "totalGasUsed"); retErr != nil {
+		return
+	}
+	gasUsed = result.TotalGasUsed
+	return
+}
+
+// ParseBundleHash returns the bundle hash from the response bytes of an eth_sendBundle or eth_callBundle request, an
+// error if the result has no bundleHash
+func ParseBundleHash(bytes []byte) (bundleHash common.Hash, retErr error) {
+	result, err := ParseSendBundleResponse(bytes)
+	if err != nil {
+		retErr = err
+		return
+	}
+	if retErr = requireResultField(bytes, "bundleHash"); retErr != nil {
+		return
+	}
+	bundleHash = result.BundleHash
+	return
+}
@@ -0,0 +1,115 @@
+package flashbots
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseBundleHash(t *testing.T) {
+	tests := []struct {
+		name            string
+		jsonResponseStr string
+		wantBundleHash  common.Hash
+		wantRPCError    bool
+		wantErr         bool
+	}{
+		{
+			name:            "send bundle response",
+			jsonResponseStr: `{"id":1,"jsonrpc":"2.0","result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`,
+			wantBundleHash:  common.HexToHash("0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"),
+		},
+		{
+			name:            "error envelope",
+			jsonResponseStr: `{"id":1,"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid bundle"}}`,
+			wantRPCError:    true,
+			wantErr:         true,
+		},
+		{
+			name:            "null result",
+			jsonResponseStr: `{"id":1,"jsonrpc":"2.0","result":null}`,
+			wantErr:         true,
+		},
+		{
+			name:            "html error page",
+			jsonResponseStr: `<html><body>429 Too Many Requests</body></html>`,
+			wantErr:         true,
+		},
+		{
+			name:            "no bundle hash",
+			jsonResponseStr: `{"id":1,"jsonrpc":"2.0","result":{}}`,
+			wantErr:         true,
+		},
+		{
+			name:            "unexpected result",
+			jsonResponseStr: `{"id":1,"jsonrpc":"2.0","result":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}`,
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBundleHash, err := ParseBundleHash([]byte(tt.jsonResponseStr))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBundleHash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var rpcErr *RPCError
+			if errors.As(err, &rpcErr) != tt.wantRPCError {
+				t.Errorf("ParseBundleHash() error = %v, wantRPCError %v", err, tt.wantRPCError)
+			}
+			if gotBundleHash != tt.wantBundleHash {
+				t.Errorf("ParseBundleHash() = %v, want %v", gotBundleHash, tt.wantBundleHash)
+			}
+			// the deprecated helper returns "" for every response without a bundle hash
+			if got := ExtractBundleHashFromBundleResponse([]byte(tt.jsonResponseStr)); tt.wantErr && got != "" {
+				t.Errorf("ExtractBundleHashFromBundleResponse() = %v, want \"\"", got)
+			}
+		})
+	}
+}
+
+func TestParseGasUsed(t *testing.T) {
+	tests := []struct {
+		name            string
+		jsonResponseStr string
+		wantGasUsed     uint64
+		wantErr         bool
+	}{
+		{
+			name:            "generic response",
+			jsonResponseStr: `{"id":1,"jsonrpc":2.0,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65","results":[{"txHash":"0x0a9e21a9c0dd6b868b1d26d9bc6b11a549fac1fb70bc2c10ebf925c43def862c"}],"totalGasUsed":243051}}`,
+			wantGasUsed:     243051,
+		},
+		{
+			name:            "error envelope",
+			jsonResponseStr: `{"error":{"code":-32000, "message":"err: nonce too low"},"id":1,"jsonrpc":2.0}`,
+			wantErr:         true,
+		},
+		{
+			name:            "empty body",
+			jsonResponseStr: ``,
+			wantErr:         true,
+		},
+		{
+			name:            "no total gas used",
+			jsonResponseStr: `{"id":1,"jsonrpc":"2.0","result":{}}`,
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotGasUsed, err := ParseGasUsed([]byte(tt.jsonResponseStr))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseGasUsed() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotGasUsed != tt.wantGasUsed {
+				t.Errorf("ParseGasUsed() = %v, want %v", gotGasUsed, tt.wantGasUsed)
+			}
+			if got := ExtractGasUsedFromBundleResponse([]byte(tt.jsonResponseStr)); got != float64(tt.wantGasUsed) {
+				t.Errorf("ExtractGasUsedFromBundleResponse() = %v, want %v", got, tt.wantGasUsed)
+			}
+		})
+	}
+}
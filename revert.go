@@ -0,0 +1,184 @@
+package flashbots
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrRevertDataLost is the error of raw revert strings that lost bytes that were not valid UTF-8, see
+// TxExecutionError.DecodeErr
+var ErrRevertDataLost = errors.New("revert data is not valid UTF-8 and was lost in the raw revert string, only 0x hex encoded reverts can always be decoded")
+
+var (
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // errorSelector is the selector of Error(string)
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71} // panicSelector is the selector of Panic(uint256)
+)
+
+// panicReasons are the solidity compiler generated Panic(uint256) codes
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesN",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// RevertDecoder decodes revert data into a human readable reason. Error(string) and Panic(uint256) are always
+// decoded, custom errors are decoded once the ABI of the reverting contract is registered.
+type RevertDecoder struct {
+	customErrors map[[4]byte]abi.Error
+}
+
+// NewRevertDecoder creates a new RevertDecoder with the custom errors of contractABIs registered
+func NewRevertDecoder(contractABIs ...abi.ABI) *RevertDecoder {
+	d := &RevertDecoder{
+		customErrors: make(map[[4]byte]abi.Error),
+	}
+	for _, contractABI := range contractABIs {
+		d.RegisterABI(contractABI)
+	}
+	return d
+}
+
+// RegisterABI registers the custom errors of contractABI. RegisterABI must not be called concurrently with Decode.
+func (d *RevertDecoder) RegisterABI(contractABI abi.ABI) {
+	for _, e := range contractABI.Errors {
+		var selector [4]byte
+		copy(selector[:], e.ID[:4])
+		d.customErrors[selector] = e
+	}
+}
+
+// Decode returns the reason encoded in revert data, e.g. `Error("STF")`, `Panic(0x11: arithmetic underflow or overflow)`
+// or `InsufficientOutputAmount()`. A nil RevertDecoder only decodes Error(string) and Panic(uint256).
+func (d *RevertDecoder) Decode(data []byte) (reason string, retErr error) {
+	if len(data) == 0 {
+		retErr = errors.New("no revert data")
+		return
+	}
+	if len(data) < 4 {
+		retErr = fmt.Errorf("revert data too short: %s", hexutil.Encode(data))
+		return
+	}
+
+	switch {
+	case bytes.Equal(data[:4], errorSelector):
+		msg, err := abi.UnpackRevert(data)
+		if err != nil {
+			retErr = fmt.Errorf("failed to unpack Error(string): %w", err)
+			return
+		}
+		reason = fmt.Sprintf("Error(%q)", msg)
+		return
+	case bytes.Equal(data[:4], panicSelector):
+		if len(data) != 4+32 {
+			retErr = fmt.Errorf("invalid Panic(uint256) data: %s", hexutil.Encode(data))
+			return
+		}
+		code := new(big.Int).SetBytes(data[4:])
+		reason = fmt.Sprintf("Panic(0x%x)", code)
+		if code.IsUint64() {
+			if msg, ok := panicReasons[code.Uint64()]; ok {
+				reason = fmt.Sprintf("Panic(0x%x: %s)", code, msg)
+			}
+		}
+		return
+	}
+
+	if d != nil {
+		var selector [4]byte
+		copy(selector[:], data[:4])
+		if customError, ok := d.customErrors[selector]; ok {
+			args, err := customError.Unpack(data)
+			if err != nil {
+				retErr = fmt.Errorf("failed to unpack %s: %w", customError.Sig, err)
+				return
+			}
+			argStrings := make([]string, len(args.([]interface{})))
+			for i, arg := range args.([]interface{}) {
+				argStrings[i] = fmt.Sprintf("%v", arg)
+			}
+			reason = fmt.Sprintf("%s(%s)", customError.Name, strings.Join(argStrings, ", "))
+			return
+		}
+	}
+
+	retErr = fmt.Errorf("unknown error selector %s", hexutil.Encode(data[:4]))
+	return
+}
+
+// TxExecutionError is the error of a single transaction that failed in a bundle simulation
+type TxExecutionError struct {
+	Index      int            // Index of the transaction in the bundle
+	TxHash     common.Hash    // TxHash of the failed transaction
+	From       common.Address // From is the sender of the failed transaction
+	To         common.Address // To is the recipient of the failed transaction
+	Err        string         // Err is the execution error, e.g. "execution reverted"
+	RevertData []byte         // RevertData is the raw revert data, empty if the transaction did not revert or it was lost
+	Reason     string         // Reason is the decoded RevertData, empty if it could not be decoded
+	DecodeErr  error          // DecodeErr is why Reason is empty, nil if the transaction did not revert
+}
+
+func (e *TxExecutionError) Error() string {
+	reason := e.Reason
+	if reason == "" && len(e.RevertData) > 0 {
+		reason = hexutil.Encode(e.RevertData)
+	}
+	if reason == "" {
+		reason = e.Err
+	}
+	return fmt.Sprintf("tx %d (%s): %s", e.Index, e.TxHash.Hex(), reason)
+}
+
+// ExecutionErrors returns a *TxExecutionError for every transaction that failed in the simulation, with revert data
+// decoded by decoder. decoder may be nil.
+func (c CallBundleResult) ExecutionErrors(decoder *RevertDecoder) (errs []*TxExecutionError) {
+	errs = make([]*TxExecutionError, 0)
+	for i, tx := range c.Results {
+		if !tx.Failed() {
+			continue
+		}
+		txErr := &TxExecutionError{
+			Index:  i,
+			TxHash: tx.TxHash,
+			From:   tx.FromAddress,
+			To:     tx.ToAddress,
+			Err:    tx.Error,
+		}
+		txErr.RevertData, txErr.DecodeErr = revertData(tx.Revert)
+		if txErr.DecodeErr == nil && len(txErr.RevertData) > 0 {
+			txErr.Reason, txErr.DecodeErr = decoder.Decode(txErr.RevertData)
+		}
+		errs = append(errs, txErr)
+	}
+	return
+}
+
+// revertData converts the revert field of an eth_callBundle transaction result into bytes. Some simulators return it
+// hex encoded. mev-geth returns the raw revert data as a string instead, which replaces every byte that is not valid
+// UTF-8 with U+FFFD when it is encoded to JSON. The Error(string) selector is not valid UTF-8, so such reverts
+// fail with ErrRevertDataLost.
+func revertData(revert string) (data []byte, retErr error) {
+	if strings.HasPrefix(revert, "0x") {
+		if data, err := hexutil.Decode(revert); err == nil {
+			return data, nil
+		}
+	}
+	if strings.ContainsRune(revert, utf8.RuneError) {
+		return nil, ErrRevertDataLost
+	}
+	return []byte(revert), nil
+}
@@ -0,0 +1,144 @@
+package flashbots
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const testPairABI = `[{"inputs":[],"name":"InsufficientOutputAmount","type":"error"},{"inputs":[{"internalType":"uint256","name":"available","type":"uint256"},{"internalType":"uint256","name":"required","type":"uint256"}],"name":"InsufficientBalance","type":"error"}]`
+
+func TestRevertDecoder_Decode(t *testing.T) {
+	pairABI, err := abi.JSON(strings.NewReader(testPairABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		decoder    *RevertDecoder
+		data       string
+		wantReason string
+		wantErr    bool
+	}{
+		{
+			name:       "Error(string)",
+			decoder:    nil,
+			data:       "0x08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000035354460000000000000000000000000000000000000000000000000000000000",
+			wantReason: `Error("STF")`,
+		},
+		{
+			name:       "Panic(uint256)",
+			decoder:    nil,
+			data:       "0x4e487b710000000000000000000000000000000000000000000000000000000000000011",
+			wantReason: "Panic(0x11: arithmetic underflow or overflow)",
+		},
+		{
+			name:       "unknown panic code",
+			decoder:    nil,
+			data:       "0x4e487b710000000000000000000000000000000000000000000000000000000000000099",
+			wantReason: "Panic(0x99)",
+		},
+		{
+			name:       "custom error",
+			decoder:    NewRevertDecoder(pairABI),
+			data:       "0x42301c23",
+			wantReason: "InsufficientOutputAmount()",
+		},
+		{
+			name:       "custom error with arguments",
+			decoder:    NewRevertDecoder(pairABI),
+			data:       "0xcf47918100000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002",
+			wantReason: "InsufficientBalance(1, 2)",
+		},
+		{
+			name:    "unregistered custom error",
+			decoder: nil,
+			data:    "0x42301c23",
+			wantErr: true,
+		},
+		{
+			name:    "no data",
+			decoder: NewRevertDecoder(),
+			data:    "0x",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotReason, err := tt.decoder.Decode(common.FromHex(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotReason != tt.wantReason {
+				t.Errorf("Decode() = %v, want %v", gotReason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCallBundleResult_ExecutionErrors(t *testing.T) {
+	pairABI, err := abi.JSON(strings.NewReader(testPairABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ParseCallBundleResponse([]byte(`{"id":1,"jsonrpc":"2.0","result":{"bundleHash":"0x0ccf11afd8f1aaeb05d5057d79395a612e35d589ead6bb63e5caef2d5e7b670f","results":[{"gasUsed":21000,"txHash":"0x669b4704a7d993a946cdd6e2f95233f308ce0c4649d2e04944e8299efcaa098a"},{"error":"execution reverted","gasUsed":240600,"revert":"0x42301c23","fromAddress":"0x3cA43755058a2294Fb280DfF9127db6F9c2216EA","toAddress":"0x162Ab7D33ab2f61A5c380a37F7b516EDaFd77913","txHash":"0xabc8eb8ca3f66072aba73063332edc8d86904febd5f85923cc44d289ecaf2623"},{"error":"out of gas","gasUsed":100000,"txHash":"0xa839ee83465657cac01adc1d50d96c1b586ed498120a84a64749c0034b4f19fa"}],"totalGasUsed":361600}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := result.ExecutionErrors(NewRevertDecoder(pairABI))
+	wantErrs := []string{
+		"tx 1 (0xabc8eb8ca3f66072aba73063332edc8d86904febd5f85923cc44d289ecaf2623): InsufficientOutputAmount()",
+		"tx 2 (0xa839ee83465657cac01adc1d50d96c1b586ed498120a84a64749c0034b4f19fa): out of gas",
+	}
+	if len(errs) != len(wantErrs) {
+		t.Fatalf("ExecutionErrors() len = %v, want %v", len(errs), len(wantErrs))
+	}
+	for i := range errs {
+		if errs[i].Error() != wantErrs[i] {
+			t.Errorf("ExecutionErrors()[%d]\ngot:  %v\nwant: %v", i, errs[i].Error(), wantErrs[i])
+		}
+	}
+	if errs[0].From != common.HexToAddress("0x3cA43755058a2294Fb280DfF9127db6F9c2216EA") {
+		t.Errorf("ExecutionErrors()[0].From = %v", errs[0].From.Hex())
+	}
+}
+
+func TestCallBundleResult_ExecutionErrors_rawRevert(t *testing.T) {
+	errorData := hexutil.MustDecode("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"5354460000000000000000000000000000000000000000000000000000000000")
+	panicData := hexutil.MustDecode("0x4e487b71" +
+		"0000000000000000000000000000000000000000000000000000000000000011")
+
+	// mev-geth encodes the revert data as a raw string, replacing invalid UTF-8 with U+FFFD
+	results, err := json.Marshal([]map[string]interface{}{
+		{"error": "execution reverted", "revert": string(errorData), "txHash": "0xabc8eb8ca3f66072aba73063332edc8d86904febd5f85923cc44d289ecaf2623"},
+		{"error": "execution reverted", "revert": string(panicData), "txHash": "0xa839ee83465657cac01adc1d50d96c1b586ed498120a84a64749c0034b4f19fa"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ParseCallBundleResponse([]byte(`{"id":1,"jsonrpc":"2.0","result":{"bundleHash":"0x0ccf11afd8f1aaeb05d5057d79395a612e35d589ead6bb63e5caef2d5e7b670f","results":` + string(results) + `,"totalGasUsed":42000}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := result.ExecutionErrors(nil)
+	if len(errs) != 2 {
+		t.Fatalf("ExecutionErrors() len = %v, want 2", len(errs))
+	}
+	if !errors.Is(errs[0].DecodeErr, ErrRevertDataLost) || errs[0].RevertData != nil || errs[0].Reason != "" {
+		t.Errorf("ExecutionErrors()[0] of a lost Error(string) revert = %+v, want ErrRevertDataLost", errs[0])
+	}
+	if want := "Panic(0x11: arithmetic underflow or overflow)"; errs[1].Reason != want || errs[1].DecodeErr != nil {
+		t.Errorf("ExecutionErrors()[1].Reason = %q (%v), want %q", errs[1].Reason, errs[1].DecodeErr, want)
+	}
+}
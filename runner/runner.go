@@ -0,0 +1,144 @@
+// Package runner runs the new head driven submission loop: for every new head, build bundles for the next block and
+// send them to all relays, aborting sends for a block once the next head arrives.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/wphan/go-flashbots"
+)
+
+// HeaderSource delivers new chain heads. *ethclient.Client satisfies HeaderSource, HeaderChannel adapts a channel.
+type HeaderSource interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// HeaderChannel is a HeaderSource forwarding the headers received on a channel
+type HeaderChannel <-chan *types.Header
+
+func (h HeaderChannel) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		for {
+			select {
+			case head, ok := <-h:
+				if !ok {
+					return errors.New("header channel closed")
+				}
+				select {
+				case ch <- head:
+				case <-quit:
+					return nil
+				}
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// BuildFunc returns the bundles to send after head. ctx is canceled when the next head arrives.
+type BuildFunc func(ctx context.Context, head *types.Header) ([]flashbots.Bundle, error)
+
+// Result is the outcome of a single head
+type Result struct {
+	Head      *types.Header
+	Bundles   []flashbots.Bundle
+	Responses []map[string]flashbots.SendBundleResponse // Responses of every relay, in the order of Bundles
+	Err       error                                     // Err is the error returned by BuildFunc
+}
+
+type Runner struct {
+	relay    flashbots.Relay
+	headers  HeaderSource
+	build    BuildFunc
+	onResult func(Result)
+
+	resultMu sync.Mutex // resultMu serializes the onResult calls of the heads still in flight
+}
+
+// NewRunner creates a new submission loop
+// relay:    relays every bundle is sent to
+// headers:  source of new heads
+// build:    builds the bundles for a head
+// onResult: called once the bundles of a head are sent, one call at a time, may be nil
+func NewRunner(relay flashbots.Relay, headers HeaderSource, build BuildFunc, onResult func(Result)) (r *Runner, retErr error) {
+	if relay == nil || headers == nil || build == nil {
+		retErr = errors.New("must provide a relay, header source and build function")
+		return
+	}
+	r = &Runner{
+		relay:    relay,
+		headers:  headers,
+		build:    build,
+		onResult: onResult,
+	}
+	return
+}
+
+// Run subscribes to new heads and handles every head until ctx is canceled or the subscription fails. The work of a
+// head is canceled when the next head arrives. Run waits for in flight work to stop before returning.
+func (r *Runner) Run(ctx context.Context) (retErr error) {
+	heads := make(chan *types.Header)
+	sub, err := r.headers.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		retErr = fmt.Errorf("failed to subscribe to new heads: %w", err)
+		return
+	}
+	defer sub.Unsubscribe()
+
+	var wg sync.WaitGroup
+	cancelBlock := func() {}
+	defer func() {
+		cancelBlock()
+		wg.Wait()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			retErr = ctx.Err()
+			return
+		case err := <-sub.Err():
+			retErr = fmt.Errorf("new head subscription failed: %w", err)
+			return
+		case head := <-heads:
+			cancelBlock()
+			blockCtx, cancel := context.WithCancel(ctx)
+			cancelBlock = cancel
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.handleHead(blockCtx, head)
+			}()
+		}
+	}
+}
+
+func (r *Runner) handleHead(ctx context.Context, head *types.Header) {
+	result := Result{Head: head}
+	result.Bundles, result.Err = r.build(ctx, head)
+	if result.Err == nil {
+		result.Responses = make([]map[string]flashbots.SendBundleResponse, len(result.Bundles))
+		var wg sync.WaitGroup
+		for i := range result.Bundles {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				result.Responses[i] = r.relay.Send(ctx, result.Bundles[i])
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	if r.onResult != nil {
+		r.resultMu.Lock()
+		defer r.resultMu.Unlock()
+		r.onResult(result)
+	}
+}
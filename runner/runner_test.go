@@ -0,0 +1,150 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/wphan/go-flashbots"
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestRunner_Run(t *testing.T) {
+	// the relay hangs on bundles for block 101 until the request is canceled
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		var payload struct {
+			Params []flashbots.Bundle `json:"params"`
+		}
+		_ = json.Unmarshal(body, &payload)
+		if payload.Params[0].BlockNumber == "0x65" {
+			<-req.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	relayA, _ := flashbots.NewRelayClient(pkey, "a", server.URL, "")
+	relayB, _ := flashbots.NewRelayClient(pkey, "b", server.URL, "")
+	batch, _ := flashbots.NewBatchRelayClientFromRelayClients(relayA, relayB)
+
+	heads := make(chan *types.Header)
+	started := make(chan struct{})
+	var mu sync.Mutex
+	results := make(map[uint64]Result)
+	handled := make(chan uint64, 3)
+	r, err := NewRunner(batch, HeaderChannel(heads), func(ctx context.Context, head *types.Header) ([]flashbots.Bundle, error) {
+		if head.Number.Uint64() == 102 {
+			return nil, errors.New("no opportunity")
+		}
+		b, err := flashbots.NewBundle(nil, head.Number.Uint64()+1, 0, nil, nil, nil)
+		if head.Number.Uint64() == 100 {
+			close(started)
+		}
+		return []flashbots.Bundle{b, b}, err
+	}, func(result Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[result.Head.Number.Uint64()] = result
+		handled <- result.Head.Number.Uint64()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+	go func() { runErr <- r.Run(ctx) }()
+
+	heads <- &types.Header{Number: big.NewInt(100)}
+	<-started
+	time.Sleep(50 * time.Millisecond)
+	heads <- &types.Header{Number: big.NewInt(101)}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-handled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for results")
+		}
+	}
+	heads <- &types.Header{Number: big.NewInt(102)}
+	<-handled
+	cancel()
+	if err := <-runErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+
+	for _, resps := range results[100].Responses {
+		for name, resp := range resps {
+			if !errors.Is(resp.Error, context.Canceled) {
+				t.Errorf("head 100 relay %s error = %v, want context.Canceled", name, resp.Error)
+			}
+		}
+	}
+	if len(results[101].Responses) != 2 {
+		t.Fatalf("head 101 responses = %v, want 2", len(results[101].Responses))
+	}
+	for _, resps := range results[101].Responses {
+		if len(resps) != 2 {
+			t.Errorf("head 101 sent to %d relays, want 2", len(resps))
+		}
+		for name, resp := range resps {
+			if resp.Error != nil {
+				t.Errorf("head 101 relay %s error = %v", name, resp.Error)
+			}
+		}
+	}
+	if results[102].Err == nil || results[102].Responses != nil {
+		t.Errorf("head 102 = %+v, want build error and no sends", results[102])
+	}
+}
+
+func TestRunner_Run_serialResults(t *testing.T) {
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	relay, _ := flashbots.NewRelayClient(pkey, "relay", "http://127.0.0.1:1", "")
+
+	// the build of head 100 ends when head 101 arrives, so both results are ready at the same time
+	heads := make(chan *types.Header)
+	started := make(chan struct{})
+	var active int32
+	handled := make(chan struct{}, 2)
+	r, _ := NewRunner(relay, HeaderChannel(heads), func(ctx context.Context, head *types.Header) ([]flashbots.Bundle, error) {
+		if head.Number.Uint64() == 100 {
+			close(started)
+			<-ctx.Done()
+		}
+		return nil, errors.New("no opportunity")
+	}, func(result Result) {
+		if atomic.AddInt32(&active, 1) != 1 {
+			t.Error("onResult called concurrently")
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		handled <- struct{}{}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = r.Run(ctx) }()
+	heads <- &types.Header{Number: big.NewInt(100)}
+	<-started
+	heads <- &types.Header{Number: big.NewInt(101)}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-handled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for results")
+		}
+	}
+}
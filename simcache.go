@@ -0,0 +1,204 @@
+package flashbots
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Hash returns the Flashbots bundle hash of b, the keccak256 hash of its transaction hashes concatenated. It is
+// computed locally from TxsByteString.
+func (b Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.TxsByteString)*common.HashLength)
+	for _, tx := range b.TxsByteString {
+		hashes = append(hashes, crypto.Keccak256(common.FromHex(tx))...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// SimulationCacheOptions bounds a SimulationCache. Zero values use the defaults.
+type SimulationCacheOptions struct {
+	TTL        time.Duration // TTL is how long a simulation is cached, default 12s
+	MaxEntries int           // MaxEntries is the maximum number of cached simulations, the least recently used are evicted first, default 1024
+}
+
+// SimulationCacheStats counts the lookups of a SimulationCache
+type SimulationCacheStats struct {
+	Hits    uint64 // Hits are simulations answered from the cache
+	Misses  uint64 // Misses are simulations sent to the simulation server
+	Shared  uint64 // Shared are simulations that waited for an identical simulation in flight
+	Entries int    // Entries is the number of cached simulations
+}
+
+type simulationKey struct {
+	bundleHash common.Hash // bundleHash is the local hash of the bundle
+	stateBlock string      // stateBlock is the state block number simulated on
+	params     common.Hash // params is the hash of the other bundle fields and the simulation overrides
+}
+
+type simulationEntry struct {
+	key     simulationKey
+	resp    SendBundleResponse
+	expires time.Time
+}
+
+type simulationFlight struct {
+	done chan struct{}
+	resp SendBundleResponse
+}
+
+// SimulationCache caches successful eth_callBundle responses by bundle, state block and simulation overrides, and
+// deduplicates identical simulations in flight so only one of them reaches the simulation server. Bundles simulated
+// on a block tag such as "latest" are never cached. Cached responses share their ResponseBytes, which must not be
+// modified.
+type SimulationCache struct {
+	opts SimulationCacheOptions
+
+	mu      sync.Mutex
+	entries map[simulationKey]*list.Element
+	lru     *list.List // lru holds *simulationEntry, most recently used first
+	flights map[simulationKey]*simulationFlight
+	stats   SimulationCacheStats
+}
+
+// NewSimulationCache creates a new simulation cache
+func NewSimulationCache(opts SimulationCacheOptions) *SimulationCache {
+	if opts.TTL <= 0 {
+		opts.TTL = SlotDuration
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1024
+	}
+	return &SimulationCache{
+		opts:    opts,
+		entries: make(map[simulationKey]*list.Element),
+		lru:     list.New(),
+		flights: make(map[simulationKey]*simulationFlight),
+	}
+}
+
+// SetSimulationCache puts cache in front of the simulations of r, nil to remove it. A cache may be shared by relay
+// clients that simulate against the same state. SetSimulationCache must not be called concurrently with requests.
+func (r *RelayClient) SetSimulationCache(cache *SimulationCache) {
+	r.simulationCache = cache
+}
+
+// Stats returns the lookup counts of c
+func (c *SimulationCache) Stats() SimulationCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+// simulationKeyOf returns the cache key of simulating b with opts, false if the simulation must not be cached
+func simulationKeyOf(b Bundle, opts *SimulationOptions) (key simulationKey, ok bool) {
+	if !strings.HasPrefix(b.StateBlockNumber, "0x") || b.StateBlockNumber == "0x0" {
+		return
+	}
+	params, err := json.Marshal(newCallBundleArgs(b, opts))
+	if err != nil {
+		return
+	}
+	return simulationKey{
+		bundleHash: b.Hash(),
+		stateBlock: strings.ToLower(b.StateBlockNumber),
+		params:     crypto.Keccak256Hash(params),
+	}, true
+}
+
+// simulate returns the cached simulation of b with opts, or runs simulate once for all identical concurrent callers
+func (c *SimulationCache) simulate(ctx context.Context, b Bundle, opts *SimulationOptions, simulate func(ctx context.Context) SendBundleResponse) SendBundleResponse {
+	key, ok := simulationKeyOf(b, opts)
+	if !ok {
+		return simulate(ctx)
+	}
+
+	for {
+		c.mu.Lock()
+		if elem, ok := c.entries[key]; ok {
+			entry := elem.Value.(*simulationEntry)
+			if time.Now().Before(entry.expires) {
+				c.lru.MoveToFront(elem)
+				c.stats.Hits++
+				c.mu.Unlock()
+				return entry.resp
+			}
+			c.lru.Remove(elem)
+			delete(c.entries, key)
+		}
+
+		if flight, ok := c.flights[key]; ok {
+			c.stats.Shared++
+			c.mu.Unlock()
+			select {
+			case <-flight.done:
+			case <-ctx.Done():
+				return SendBundleResponse{
+					Error: ctx.Err(),
+				}
+			}
+			// the simulation in flight was canceled by its caller, try again if ours was not
+			if isContextError(flight.resp.Error) && ctx.Err() == nil {
+				continue
+			}
+			return flight.resp
+		}
+
+		flight := &simulationFlight{
+			done: make(chan struct{}),
+		}
+		c.flights[key] = flight
+		c.stats.Misses++
+		c.mu.Unlock()
+
+		flight.resp = simulate(ctx)
+
+		c.mu.Lock()
+		delete(c.flights, key)
+		if cacheable(flight.resp) {
+			c.add(key, flight.resp)
+		}
+		c.mu.Unlock()
+		close(flight.done)
+		return flight.resp
+	}
+}
+
+// add caches resp under key, evicting the least recently used entry if c is full. c.mu must be held.
+func (c *SimulationCache) add(key simulationKey, resp SendBundleResponse) {
+	c.entries[key] = c.lru.PushFront(&simulationEntry{
+		key:     key,
+		resp:    resp,
+		expires: time.Now().Add(c.opts.TTL),
+	})
+	for c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*simulationEntry).key)
+	}
+}
+
+// cacheable is true if resp reached the simulation server and holds a result
+func cacheable(resp SendBundleResponse) bool {
+	if resp.Error != nil {
+		return false
+	}
+	var envelope rpcResponse
+	if err := json.Unmarshal(resp.ResponseBytes, &envelope); err != nil {
+		return false
+	}
+	return envelope.Error == nil && len(envelope.Result) > 0 && string(envelope.Result) != "null"
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
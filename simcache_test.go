@@ -0,0 +1,111 @@
+package flashbots
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestBundle_Hash(t *testing.T) {
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	signer := types.NewLondonSigner(big.NewInt(1))
+	var b Bundle
+	hashes := make([]byte, 0)
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx := types.MustSignNewTx(pkey, signer, &types.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     nonce,
+			Gas:       21000,
+			GasFeeCap: big.NewInt(100),
+		})
+		if err := b.AddTransaction(tx); err != nil {
+			t.Fatalf("AddTransaction() error = %v", err)
+		}
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	if got, want := b.Hash(), crypto.Keccak256Hash(hashes); got != want {
+		t.Errorf("Hash() = %v, want %v", got.Hex(), want.Hex())
+	}
+}
+
+func TestRelayClient_SetSimulationCache(t *testing.T) {
+	var simulations int32
+	fail := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var payload rpcPaylod
+		_ = json.NewDecoder(req.Body).Decode(&payload)
+		atomic.AddInt32(&simulations, 1)
+		time.Sleep(20 * time.Millisecond)
+		if atomic.LoadInt32(&fail) == 1 {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"nonce too low"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"results":[],"totalGasUsed":21000}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	r, _ := NewRelayClient(pkey, "relay", server.URL, server.URL)
+	cache := NewSimulationCache(SimulationCacheOptions{TTL: 100 * time.Millisecond, MaxEntries: 1})
+	r.SetSimulationCache(cache)
+
+	b := Bundle{TxsByteString: []string{"0x01"}, BlockNumber: "0x11", StateBlockNumber: "0x10"}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp := r.SimulateBundleContext(context.Background(), b, nil); resp.Error != nil {
+				t.Errorf("SimulateBundleContext() error = %v", resp.Error)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := atomic.LoadInt32(&simulations); got != 1 {
+		t.Errorf("simulations = %v, want identical concurrent simulations sent once", got)
+	}
+	if _, _, err := r.SimulateBundle(b, nil); err != nil {
+		t.Errorf("SimulateBundle() error = %v", err)
+	}
+	if stats := cache.Stats(); stats.Misses != 1 || stats.Shared != 4 || stats.Hits != 1 || stats.Entries != 1 {
+		t.Errorf("Stats() = %+v, want 1 miss, 4 shared and 1 hit", stats)
+	}
+
+	tests := []struct {
+		name   string
+		b      Bundle
+		opts   *SimulationOptions
+		cached bool
+	}{
+		{"other overrides", b, &SimulationOptions{BaseFee: big.NewInt(7)}, false},
+		{"evicted by other overrides", b, nil, false},
+		{"latest state block", Bundle{TxsByteString: []string{"0x01"}, BlockNumber: "0x11"}, nil, false},
+		{"still cached", b, nil, true},
+	}
+	for _, tt := range tests {
+		before := atomic.LoadInt32(&simulations)
+		r.SimulateBundle(tt.b, tt.opts)
+		if cached := atomic.LoadInt32(&simulations) == before; cached != tt.cached {
+			t.Errorf("%s: cached = %v, want %v", tt.name, cached, tt.cached)
+		}
+	}
+
+	time.Sleep(110 * time.Millisecond)
+	atomic.StoreInt32(&fail, 1)
+	before := atomic.LoadInt32(&simulations)
+	r.SimulateBundle(b, nil)
+	r.SimulateBundle(b, nil)
+	if got := atomic.LoadInt32(&simulations) - before; got != 2 {
+		t.Errorf("simulations after expiry = %v, want 2, errors must not be cached", got)
+	}
+}
@@ -0,0 +1,257 @@
+package flashbots
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"sync"
+	"time"
+)
+
+// SimulationBackend simulates bundles with eth_callBundle for a RelayClient, see SetSimulationBackend
+type SimulationBackend interface {
+	SimulateBundle(ctx context.Context, b Bundle, opts *SimulationOptions) SendBundleResponse
+}
+
+// SetSimulationBackend makes r simulate bundles on backend instead of its simulation endpoints, nil to use the
+// simulation endpoints again. SetSimulationBackend must not be called concurrently with requests.
+func (r *RelayClient) SetSimulationBackend(backend SimulationBackend) {
+	r.simulationBackend = backend
+}
+
+// CanSimulate is true if r has a simulation backend or a simulation endpoint
+func (r RelayClient) CanSimulate() bool {
+	return r.simulationBackend != nil || len(r.simulationEndpoints) > 0
+}
+
+// SimulatorPoolOptions configures a SimulatorPool. Zero values use the defaults.
+type SimulatorPoolOptions struct {
+	MaxConcurrency int           // MaxConcurrency is the maximum number of simulations in flight per node, 0 for no limit
+	UnhealthyAfter int           // UnhealthyAfter is the number of consecutive failures that mark a node unhealthy, default 3
+	HealthTimeout  time.Duration // HealthTimeout bounds a health check request, default 2s
+}
+
+// SimulatorNodeStatus is a snapshot of a node of a SimulatorPool
+type SimulatorNodeStatus struct {
+	Endpoint  string
+	Healthy   bool
+	InFlight  int    // InFlight is the number of simulations currently running on the node
+	Requests  uint64 // Requests is the number of simulations sent to the node
+	Failures  uint64 // Failures is the number of simulations that failed to reach the node
+	LastError error  // LastError is the most recent request or health check error, nil after a success
+}
+
+type simulatorNode struct {
+	client              *RelayClient
+	healthy             bool
+	inFlight            int
+	requests            uint64
+	failures            uint64
+	consecutiveFailures int
+	lastError           error
+}
+
+// SimulatorPool load-balances bundle simulations over several simulation nodes, e.g. local mev-geth nodes. Each
+// simulation goes to the healthy node with the fewest simulations in flight, waiting for a free slot if every node
+// is at MaxConcurrency. Nodes are marked unhealthy after UnhealthyAfter consecutive failed requests and healthy
+// again by a successful health check. When no node is healthy, unhealthy ones are used rather than failing.
+// SimulatorPool is a SimulationBackend.
+type SimulatorPool struct {
+	opts SimulatorPoolOptions
+
+	mu       sync.Mutex
+	nodes    []*simulatorNode
+	released chan struct{} // released is closed and replaced whenever a simulation finishes
+}
+
+// NewSimulatorPool creates a new simulator pool
+// signingPrivateKey: the key used to sign requests (this can be any valid private key)
+// endpoints:         the simulation endpoints of the nodes
+func NewSimulatorPool(signingPrivateKey *ecdsa.PrivateKey, endpoints []string, opts SimulatorPoolOptions) (p *SimulatorPool, retErr error) {
+	if len(endpoints) == 0 {
+		retErr = errors.New("must provide at least one simulation endpoint")
+		return
+	}
+	if opts.UnhealthyAfter <= 0 {
+		opts.UnhealthyAfter = 3
+	}
+	if opts.HealthTimeout <= 0 {
+		opts.HealthTimeout = 2 * time.Second
+	}
+
+	nodes := make([]*simulatorNode, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		client, err := NewRelayClientWithEndpoints(signingPrivateKey, endpoint, []string{endpoint}, []string{endpoint})
+		if err != nil {
+			retErr = err
+			return
+		}
+		nodes = append(nodes, &simulatorNode{
+			client:  client,
+			healthy: true,
+		})
+	}
+
+	p = &SimulatorPool{
+		opts:     opts,
+		nodes:    nodes,
+		released: make(chan struct{}),
+	}
+	return
+}
+
+// SimulateBundle simulates b on the least loaded healthy node. The response's Endpoint is the node that served it.
+func (p *SimulatorPool) SimulateBundle(ctx context.Context, b Bundle, opts *SimulationOptions) (resp SendBundleResponse) {
+	node, err := p.acquire(ctx)
+	if err != nil {
+		return SendBundleResponse{
+			Error: err,
+		}
+	}
+	resp = node.client.simulateBundle(ctx, b, opts)
+	p.release(ctx, node, resp.Error)
+	return
+}
+
+// acquire reserves a slot on the healthy node with the fewest simulations in flight, or on an unhealthy one if no
+// node is healthy, waiting until ctx is done if every candidate node is full
+func (p *SimulatorPool) acquire(ctx context.Context) (*simulatorNode, error) {
+	for {
+		p.mu.Lock()
+		node := p.leastLoaded(true)
+		if node == nil && !p.hasHealthy() {
+			node = p.leastLoaded(false)
+		}
+		if node != nil {
+			node.inFlight++
+			node.requests++
+			p.mu.Unlock()
+			return node, nil
+		}
+		released := p.released
+		p.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+// leastLoaded returns the node below MaxConcurrency with the fewest simulations in flight among the nodes with the
+// given health, nil if there is none. p.mu must be held.
+func (p *SimulatorPool) leastLoaded(healthy bool) (best *simulatorNode) {
+	for _, node := range p.nodes {
+		if node.healthy != healthy {
+			continue
+		}
+		if p.opts.MaxConcurrency > 0 && node.inFlight >= p.opts.MaxConcurrency {
+			continue
+		}
+		if best == nil || node.inFlight < best.inFlight {
+			best = node
+		}
+	}
+	return
+}
+
+// hasHealthy is true if any node is healthy. p.mu must be held.
+func (p *SimulatorPool) hasHealthy() bool {
+	for _, node := range p.nodes {
+		if node.healthy {
+			return true
+		}
+	}
+	return false
+}
+
+// release frees the slot of a simulation sent with ctx on node. Failures because ctx ended are not held against
+// node.
+func (p *SimulatorPool) release(ctx context.Context, node *simulatorNode, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	node.inFlight--
+	if err != nil && ctx.Err() == nil && !errors.Is(err, context.Canceled) {
+		node.failures++
+		node.consecutiveFailures++
+		node.lastError = err
+		if node.consecutiveFailures >= p.opts.UnhealthyAfter {
+			node.healthy = false
+		}
+	} else if err == nil {
+		node.consecutiveFailures = 0
+		node.lastError = nil
+	}
+	close(p.released)
+	p.released = make(chan struct{})
+}
+
+// CheckHealth checks every node with an eth_blockNumber request and updates its health
+func (p *SimulatorPool) CheckHealth(ctx context.Context) {
+	payload, err := p.nodes[0].client.prepareWarmPayload()
+	if err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range p.nodes {
+		wg.Add(1)
+		go func(node *simulatorNode) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, p.opts.HealthTimeout)
+			defer cancel()
+			responseBytes, _, err := node.client.fbRequest(checkCtx, node.client.SimulationEndpoint(), payload)
+			if err == nil {
+				var result string
+				err = decodeRPCResponse(responseBytes, &result)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			node.healthy = err == nil
+			node.lastError = err
+			if err == nil {
+				node.consecutiveFailures = 0
+			}
+		}(node)
+	}
+	wg.Wait()
+}
+
+// RunHealthChecks checks the health of every node immediately and then every interval until ctx is done. It
+// blocks, run it in its own goroutine.
+func (p *SimulatorPool) RunHealthChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.CheckHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Nodes returns a snapshot of every node of the pool
+func (p *SimulatorPool) Nodes() (nodes []SimulatorNodeStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	nodes = make([]SimulatorNodeStatus, 0, len(p.nodes))
+	for _, node := range p.nodes {
+		nodes = append(nodes, SimulatorNodeStatus{
+			Endpoint:  node.client.SimulationEndpoint(),
+			Healthy:   node.healthy,
+			InFlight:  node.inFlight,
+			Requests:  node.requests,
+			Failures:  node.failures,
+			LastError: node.lastError,
+		})
+	}
+	return
+}
@@ -0,0 +1,143 @@
+package flashbots
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+func newSimulatorServer(block chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var payload rpcPaylod
+		_ = json.NewDecoder(req.Body).Decode(&payload)
+		if payload.Method == warmMethod {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+			return
+		}
+		if block != nil {
+			<-block
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65","results":[],"totalGasUsed":21000}}`))
+	}))
+}
+
+func TestSimulatorPool_leastOutstanding(t *testing.T) {
+	block := make(chan struct{})
+	a, b := newSimulatorServer(block), newSimulatorServer(block)
+	defer a.Close()
+	defer b.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	pool, err := NewSimulatorPool(pkey, []string{a.URL, b.URL}, SimulatorPoolOptions{MaxConcurrency: 1})
+	if err != nil {
+		t.Fatalf("NewSimulatorPool() error = %v", err)
+	}
+
+	resps := make(chan SendBundleResponse, 3)
+	for i := 0; i < 3; i++ {
+		go func() { resps <- pool.SimulateBundle(context.Background(), Bundle{}, nil) }()
+	}
+	time.Sleep(50 * time.Millisecond)
+	for _, node := range pool.Nodes() {
+		if node.InFlight != 1 {
+			t.Errorf("node %s in flight = %v, want 1", node.Endpoint, node.InFlight)
+		}
+	}
+
+	close(block)
+	served := make(map[string]int)
+	for i := 0; i < 3; i++ {
+		resp := <-resps
+		if resp.Error != nil {
+			t.Fatalf("SimulateBundle() error = %v", resp.Error)
+		}
+		served[resp.Endpoint]++
+	}
+	if served[a.URL] == 0 || served[b.URL] == 0 || served[a.URL]+served[b.URL] != 3 {
+		t.Errorf("simulations served = %v, want both nodes used", served)
+	}
+
+	full, _ := NewSimulatorPool(pkey, []string{a.URL}, SimulatorPoolOptions{MaxConcurrency: 1})
+	full.nodes[0].inFlight = 1
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if resp := full.SimulateBundle(ctx, Bundle{}, nil); resp.Error != context.DeadlineExceeded {
+		t.Errorf("SimulateBundle() on a full pool error = %v, want %v", resp.Error, context.DeadlineExceeded)
+	}
+}
+
+func TestSimulatorPool_health(t *testing.T) {
+	good := newSimulatorServer(nil)
+	defer good.Close()
+	down := "http://127.0.0.1:1"
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	pool, _ := NewSimulatorPool(pkey, []string{down, good.URL}, SimulatorPoolOptions{UnhealthyAfter: 1})
+
+	r, _ := NewRelayClient(pkey, "relay", good.URL, "")
+	if r.CanSimulate() {
+		t.Errorf("CanSimulate() = true without simulation endpoint or backend")
+	}
+	r.SetSimulationBackend(pool)
+	if !r.CanSimulate() {
+		t.Errorf("CanSimulate() = false with a simulation backend")
+	}
+
+	if _, _, err := r.SimulateBundle(Bundle{}, nil); err == nil {
+		t.Errorf("SimulateBundle() on the down node error = nil")
+	}
+	if nodes := pool.Nodes(); nodes[0].Healthy || nodes[0].Failures != 1 || nodes[0].LastError == nil {
+		t.Errorf("down node = %+v, want unhealthy", nodes[0])
+	}
+	for i := 0; i < 2; i++ {
+		resp := r.SimulateBundleContext(context.Background(), Bundle{}, nil)
+		if resp.Error != nil || resp.Endpoint != good.URL {
+			t.Errorf("SimulateBundleContext() = %+v, want served by %v", resp, good.URL)
+		}
+	}
+
+	pool.nodes[1].healthy = false
+	pool.CheckHealth(context.Background())
+	nodes := pool.Nodes()
+	if nodes[0].Healthy || !nodes[1].Healthy {
+		t.Errorf("Nodes() after CheckHealth = %+v, want only the good node healthy", nodes)
+	}
+}
+
+func TestSimulatorPool_busyHealthyNodes(t *testing.T) {
+	block := make(chan struct{})
+	a, b := newSimulatorServer(block), newSimulatorServer(nil)
+	defer a.Close()
+	defer b.Close()
+	defer close(block)
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	pool, _ := NewSimulatorPool(pkey, []string{a.URL, b.URL}, SimulatorPoolOptions{MaxConcurrency: 1, UnhealthyAfter: 1})
+	pool.nodes[0].inFlight = 1
+	pool.nodes[1].healthy = false
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if resp := pool.SimulateBundle(ctx, Bundle{}, nil); resp.Error != context.DeadlineExceeded {
+		t.Errorf("SimulateBundle() with busy healthy nodes error = %v, want %v", resp.Error, context.DeadlineExceeded)
+	}
+	if nodes := pool.Nodes(); nodes[1].Requests != 0 {
+		t.Errorf("unhealthy node requests = %v, want 0 while a healthy node exists", nodes[1].Requests)
+	}
+
+	// a simulation cut short by the caller's deadline does not count against the node
+	pool.nodes[0].inFlight = 0
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if resp := pool.SimulateBundle(ctx, Bundle{}, nil); resp.Error == nil {
+		t.Errorf("SimulateBundle() on a blocked node error = nil")
+	}
+	if nodes := pool.Nodes(); !nodes[0].Healthy || nodes[0].Failures != 0 {
+		t.Errorf("node after the caller's deadline = %+v, want healthy without failures", nodes[0])
+	}
+}
@@ -0,0 +1,199 @@
+package flashbots
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// RPCError is the error object of a JSON-RPC response
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// CallBundleTxResult is the simulation result of a single transaction in an eth_callBundle response
+type CallBundleTxResult struct {
+	TxHash            common.Hash
+	FromAddress       common.Address
+	ToAddress         common.Address
+	GasUsed           uint64
+	GasPrice          *big.Int // GasPrice is the priority fee per gas paid to the coinbase
+	GasFees           *big.Int // GasFees is GasUsed * GasPrice
+	CoinbaseDiff      *big.Int // CoinbaseDiff is the coinbase balance change caused by this transaction
+	EthSentToCoinbase *big.Int // EthSentToCoinbase is the value transferred directly to the coinbase
+	Value             []byte   // Value is the return data of the transaction
+	Error             string   // Error is the execution error, empty if the transaction succeeded
+	Revert            string   // Revert is the raw revert data, empty if the transaction did not revert
+}
+
+// Failed is true if the transaction errored or reverted during simulation
+func (t CallBundleTxResult) Failed() bool {
+	return t.Error != "" || t.Revert != ""
+}
+
+// CallBundleResult is the result of an eth_callBundle request
+type CallBundleResult struct {
+	BundleHash        common.Hash
+	BundleGasPrice    *big.Int // BundleGasPrice is CoinbaseDiff / TotalGasUsed
+	CoinbaseDiff      *big.Int // CoinbaseDiff is the coinbase balance change caused by the bundle
+	EthSentToCoinbase *big.Int
+	GasFees           *big.Int
+	StateBlockNumber  uint64
+	TotalGasUsed      uint64
+	Results           []CallBundleTxResult
+}
+
+// EffectiveGasPrice is the Flashbots effective bundle gas price, the coinbase payment per unit of gas used.
+// BundleGasPrice is used if the simulation server returned it.
+func (c CallBundleResult) EffectiveGasPrice() *big.Int {
+	if c.BundleGasPrice != nil {
+		return new(big.Int).Set(c.BundleGasPrice)
+	}
+	if c.CoinbaseDiff == nil || c.TotalGasUsed == 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Div(c.CoinbaseDiff, new(big.Int).SetUint64(c.TotalGasUsed))
+}
+
+type callBundleTxResultJSON struct {
+	TxHash            common.Hash     `json:"txHash"`
+	FromAddress       common.Address  `json:"fromAddress"`
+	ToAddress         common.Address  `json:"toAddress"`
+	GasUsed           json.RawMessage `json:"gasUsed"`
+	GasPrice          json.RawMessage `json:"gasPrice"`
+	GasFees           json.RawMessage `json:"gasFees"`
+	CoinbaseDiff      json.RawMessage `json:"coinbaseDiff"`
+	EthSentToCoinbase json.RawMessage `json:"ethSentToCoinbase"`
+	Value             string          `json:"value"`
+	Error             string          `json:"error"`
+	Revert            string          `json:"revert"`
+}
+
+func (t *CallBundleTxResult) UnmarshalJSON(input []byte) (retErr error) {
+	var dec callBundleTxResultJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+
+	t.TxHash = dec.TxHash
+	t.FromAddress = dec.FromAddress
+	t.ToAddress = dec.ToAddress
+	t.Error = dec.Error
+	t.Revert = dec.Revert
+	if dec.Value != "" && dec.Value != "0x" {
+		t.Value = common.FromHex(dec.Value)
+	}
+	if t.GasUsed, retErr = parseUint64JSON(dec.GasUsed); retErr != nil {
+		return fmt.Errorf("failed to decode gasUsed: %w", retErr)
+	}
+	if t.GasPrice, retErr = parseBigIntJSON(dec.GasPrice); retErr != nil {
+		return fmt.Errorf("failed to decode gasPrice: %w", retErr)
+	}
+	if t.GasFees, retErr = parseBigIntJSON(dec.GasFees); retErr != nil {
+		return fmt.Errorf("failed to decode gasFees: %w", retErr)
+	}
+	if t.CoinbaseDiff, retErr = parseBigIntJSON(dec.CoinbaseDiff); retErr != nil {
+		return fmt.Errorf("failed to decode coinbaseDiff: %w", retErr)
+	}
+	if t.EthSentToCoinbase, retErr = parseBigIntJSON(dec.EthSentToCoinbase); retErr != nil {
+		return fmt.Errorf("failed to decode ethSentToCoinbase: %w", retErr)
+	}
+	return
+}
+
+type callBundleResultJSON struct {
+	BundleHash        common.Hash          `json:"bundleHash"`
+	BundleGasPrice    json.RawMessage      `json:"bundleGasPrice"`
+	CoinbaseDiff      json.RawMessage      `json:"coinbaseDiff"`
+	EthSentToCoinbase json.RawMessage      `json:"ethSentToCoinbase"`
+	GasFees           json.RawMessage      `json:"gasFees"`
+	StateBlockNumber  json.RawMessage      `json:"stateBlockNumber"`
+	TotalGasUsed      json.RawMessage      `json:"totalGasUsed"`
+	Results           []CallBundleTxResult `json:"results"`
+}
+
+func (c *CallBundleResult) UnmarshalJSON(input []byte) (retErr error) {
+	var dec callBundleResultJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+
+	c.BundleHash = dec.BundleHash
+	c.Results = dec.Results
+	if c.BundleGasPrice, retErr = parseBigIntJSON(dec.BundleGasPrice); retErr != nil {
+		return fmt.Errorf("failed to decode bundleGasPrice: %w", retErr)
+	}
+	if c.CoinbaseDiff, retErr = parseBigIntJSON(dec.CoinbaseDiff); retErr != nil {
+		return fmt.Errorf("failed to decode coinbaseDiff: %w", retErr)
+	}
+	if c.EthSentToCoinbase, retErr = parseBigIntJSON(dec.EthSentToCoinbase); retErr != nil {
+		return fmt.Errorf("failed to decode ethSentToCoinbase: %w", retErr)
+	}
+	if c.GasFees, retErr = parseBigIntJSON(dec.GasFees); retErr != nil {
+		return fmt.Errorf("failed to decode gasFees: %w", retErr)
+	}
+	if c.StateBlockNumber, retErr = parseUint64JSON(dec.StateBlockNumber); retErr != nil {
+		return fmt.Errorf("failed to decode stateBlockNumber: %w", retErr)
+	}
+	if c.TotalGasUsed, retErr = parseUint64JSON(dec.TotalGasUsed); retErr != nil {
+		return fmt.Errorf("failed to decode totalGasUsed: %w", retErr)
+	}
+	return
+}
+
+// ParseCallBundleResponse decodes the response bytes of an eth_callBundle request. A JSON-RPC error in the
+// response is returned as an *RPCError.
+func ParseCallBundleResponse(bytes []byte) (result CallBundleResult, retErr error) {
+	retErr = decodeRPCResponse(bytes, &result)
+	return
+}
+
+// parseBigIntJSON parses a JSON number, a quoted decimal string or a quoted hex string. Missing values are nil.
+func parseBigIntJSON(raw json.RawMessage) (*big.Int, error) {
+	s := strings.TrimSpace(string(raw))
+	if s == "" || s == "null" {
+		return nil, nil
+	}
+	s = strings.Trim(s, `"`)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		v, ok := new(big.Int).SetString(s[2:], 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid hex number: %s", s)
+		}
+		return v, nil
+	}
+	if v, ok := new(big.Int).SetString(s, 10); ok {
+		return v, nil
+	}
+	// numbers re-encoded by javascript can end up in exponent notation, e.g. 1.3051998e+07
+	f, ok := new(big.Float).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid number: %s", s)
+	}
+	v, accuracy := f.Int(nil)
+	if accuracy != big.Exact {
+		return nil, fmt.Errorf("not an integer: %s", s)
+	}
+	return v, nil
+}
+
+func parseUint64JSON(raw json.RawMessage) (uint64, error) {
+	v, err := parseBigIntJSON(raw)
+	if err != nil || v == nil {
+		return 0, err
+	}
+	if !v.IsUint64() {
+		return 0, errors.New("value does not fit in uint64: " + v.String())
+	}
+	return v.Uint64(), nil
+}
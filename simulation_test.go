@@ -0,0 +1,134 @@
+package flashbots
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParseCallBundleResponse(t *testing.T) {
+	tests := []struct {
+		name             string
+		jsonResponseStr  string
+		wantTotalGasUsed uint64
+		wantCoinbaseDiff *big.Int
+		wantResults      int
+		wantRPCError     bool
+		wantErr          bool
+	}{
+		{
+			name:             "numeric values",
+			jsonResponseStr:  `{"id":1,"jsonrpc":2.0,"result":{"bundleGasPrice":0,"bundleHash":"0x0ccf11afd8f1aaeb05d5057d79395a612e35d589ead6bb63e5caef2d5e7b670f","coinbaseDiff":0,"ethSentToCoinbase":0,"gasFees":0,"results":[{"coinbaseDiff":0,"error":"execution reverted","ethSentToCoinbase":0,"fromAddress":"0x3cA43755058a2294Fb280DfF9127db6F9c2216EA","gasFees":0,"gasPrice":0,"gasUsed":240600,"revert":"y","toAddress":"0x162Ab7D33ab2f61A5c380a37F7b516EDaFd77913","txHash":"0xabc8eb8ca3f66072aba73063332edc8d86904febd5f85923cc44d289ecaf2623"}],"stateBlockNumber":1.3051998e+07,"totalGasUsed":240600}}`,
+			wantTotalGasUsed: 240600,
+			wantCoinbaseDiff: big.NewInt(0),
+			wantResults:      1,
+		},
+		{
+			name:             "string values",
+			jsonResponseStr:  `{"jsonrpc":"2.0","id":1,"result":{"bundleGasPrice":"476190476193","bundleHash":"0x73b1e258c7a42fd0230b2fd05529c5d4b6fcb66c227783f8bece8aeacdd1db2e","coinbaseDiff":"20000000000126000","ethSentToCoinbase":"20000000000000000","gasFees":"126000","results":[{"coinbaseDiff":"10000000000063000","ethSentToCoinbase":"10000000000000000","fromAddress":"0x02A727155aeF8609c9f7F2179b2a1f560B39F5A0","gasFees":"63000","gasPrice":"476190476193","gasUsed":21000,"toAddress":"0x73625f59CAdc5009Cb458B751b3E7b6b48C06f2C","txHash":"0x669b4704a7d993a946cdd6e2f95233f308ce0c4649d2e04944e8299efcaa098a","value":"0x"}],"stateBlockNumber":5221585,"totalGasUsed":42000}}`,
+			wantTotalGasUsed: 42000,
+			wantCoinbaseDiff: big.NewInt(20000000000126000),
+			wantResults:      1,
+		},
+		{
+			name:            "rpc error",
+			jsonResponseStr: `{"error":{"code":-32000, "message":"err: nonce too low"},"id":1,"jsonrpc":2.0}`,
+			wantErr:         true,
+			wantRPCError:    true,
+		},
+		{
+			name:            "null result",
+			jsonResponseStr: `{"id":1,"jsonrpc":"2.0","result":null}`,
+			wantErr:         true,
+		},
+		{
+			name:            "html error page",
+			jsonResponseStr: `<html><body>502 Bad Gateway</body></html>`,
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCallBundleResponse([]byte(tt.jsonResponseStr))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCallBundleResponse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var rpcErr *RPCError
+			if errors.As(err, &rpcErr) != tt.wantRPCError {
+				t.Errorf("ParseCallBundleResponse() error = %v, wantRPCError %v", err, tt.wantRPCError)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.TotalGasUsed != tt.wantTotalGasUsed {
+				t.Errorf("ParseCallBundleResponse() TotalGasUsed = %v, want %v", got.TotalGasUsed, tt.wantTotalGasUsed)
+			}
+			if got.CoinbaseDiff.Cmp(tt.wantCoinbaseDiff) != 0 {
+				t.Errorf("ParseCallBundleResponse() CoinbaseDiff = %v, want %v", got.CoinbaseDiff, tt.wantCoinbaseDiff)
+			}
+			if len(got.Results) != tt.wantResults {
+				t.Errorf("ParseCallBundleResponse() len(Results) = %v, want %v", len(got.Results), tt.wantResults)
+			}
+		})
+	}
+}
+
+func TestCallBundleResult_EffectiveGasPrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		result CallBundleResult
+		want   *big.Int
+	}{
+		{
+			name:   "from bundleGasPrice",
+			result: CallBundleResult{BundleGasPrice: big.NewInt(5), CoinbaseDiff: big.NewInt(1000), TotalGasUsed: 10},
+			want:   big.NewInt(5),
+		},
+		{
+			name:   "from coinbaseDiff",
+			result: CallBundleResult{CoinbaseDiff: big.NewInt(1000), TotalGasUsed: 10},
+			want:   big.NewInt(100),
+		},
+		{
+			name:   "no gas used",
+			result: CallBundleResult{CoinbaseDiff: big.NewInt(1000)},
+			want:   big.NewInt(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.EffectiveGasPrice(); got.Cmp(tt.want) != 0 {
+				t.Errorf("EffectiveGasPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseBigIntJSON(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    *big.Int
+		wantErr bool
+	}{
+		{raw: `12`, want: big.NewInt(12)},
+		{raw: `"12"`, want: big.NewInt(12)},
+		{raw: `"0x0c"`, want: big.NewInt(12)},
+		{raw: `1.2e+01`, want: big.NewInt(12)},
+		{raw: `null`, want: nil},
+		{raw: `"abc"`, wantErr: true},
+		{raw: `1.5`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseBigIntJSON([]byte(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseBigIntJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && got.Cmp(tt.want) != 0) {
+				t.Errorf("parseBigIntJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	MainnetGenesisTime = 1606824023 // MainnetGenesisTime is the unix time of the mainnet beacon chain genesis
+	SepoliaGenesisTime = 1655733600 // SepoliaGenesisTime is the unix time of the sepolia beacon chain genesis
+	HoleskyGenesisTime = 1695902400 // HoleskyGenesisTime is the unix time of the holesky beacon chain genesis
+
+	SlotDuration = 12 * time.Second // SlotDuration is the slot duration of mainnet, sepolia and holesky
+)
+
+// ErrRelaySkipped is the error of relays skipped because their recent latency exceeds the time remaining
+var ErrRelaySkipped = errors.New("relay skipped: recent latency exceeds time remaining")
+
+// SlotClock maps time to beacon chain slots. Blocks are proposed at the start of their slot, so a block's timestamp
+// is the start time of its slot.
+type SlotClock struct {
+	genesisTime  time.Time
+	slotDuration time.Duration
+	now          func() time.Time
+}
+
+// NewSlotClock creates a new slot clock
+// genesisTime:  start time of slot 0
+// slotDuration: duration of a slot, must be a whole number of seconds
+func NewSlotClock(genesisTime time.Time, slotDuration time.Duration) (c *SlotClock, retErr error) {
+	if slotDuration < time.Second || slotDuration%time.Second != 0 {
+		retErr = errors.New("slotDuration must be a positive whole number of seconds")
+		return
+	}
+	c = &SlotClock{
+		genesisTime:  genesisTime,
+		slotDuration: slotDuration,
+		now:          time.Now,
+	}
+	return
+}
+
+func MainnetSlotClock() *SlotClock { return defaultSlotClock(MainnetGenesisTime) }
+func SepoliaSlotClock() *SlotClock { return defaultSlotClock(SepoliaGenesisTime) }
+func HoleskySlotClock() *SlotClock { return defaultSlotClock(HoleskyGenesisTime) }
+
+func defaultSlotClock(genesisTime int64) *SlotClock {
+	c, _ := NewSlotClock(time.Unix(genesisTime, 0), SlotDuration)
+	return c
+}
+
+func (c *SlotClock) GenesisTime() time.Time      { return c.genesisTime }
+func (c *SlotClock) SlotDuration() time.Duration { return c.slotDuration }
+
+// SlotAt returns the slot t is in, 0 before genesis
+func (c *SlotClock) SlotAt(t time.Time) uint64 {
+	if t.Before(c.genesisTime) {
+		return 0
+	}
+	return uint64(t.Sub(c.genesisTime) / c.slotDuration)
+}
+
+// SlotOfTimestamp returns the slot of a block with timestamp
+func (c *SlotClock) SlotOfTimestamp(timestamp uint64) uint64 {
+	return c.SlotAt(time.Unix(int64(timestamp), 0))
+}
+
+// SlotStart returns the start time of slot, which is the timestamp of the block proposed in slot
+func (c *SlotClock) SlotStart(slot uint64) time.Time {
+	return c.genesisTime.Add(time.Duration(slot) * c.slotDuration)
+}
+
+// SlotTimestamp returns the timestamp of the block proposed in slot
+func (c *SlotClock) SlotTimestamp(slot uint64) uint64 {
+	return uint64(c.SlotStart(slot).Unix())
+}
+
+// CurrentSlot returns the slot now is in
+func (c *SlotClock) CurrentSlot() uint64 {
+	return c.SlotAt(c.now())
+}
+
+// NextSlot returns the slot after the current one, the earliest slot a bundle sent now can land in
+func (c *SlotClock) NextSlot() uint64 {
+	return c.CurrentSlot() + 1
+}
+
+// TimeUntilSlot returns the time remaining until slot starts, which is the deadline for bundles targeting it.
+// It is negative if slot already started.
+func (c *SlotClock) TimeUntilSlot(slot uint64) time.Duration {
+	return c.SlotStart(slot).Sub(c.now())
+}
+
+// TimeUntilNextSlot returns the time remaining until the next slot starts
+func (c *SlotClock) TimeUntilNextSlot() time.Duration {
+	return c.TimeUntilSlot(c.NextSlot())
+}
+
+// SetSlot restricts b to the block proposed in slot by setting its MinTimestamp and MaxTimestamp to the slot's
+// block timestamp
+func (b *Bundle) SetSlot(clock *SlotClock, slot uint64) {
+	timestamp := int(clock.SlotTimestamp(slot))
+	minTimestamp, maxTimestamp := timestamp, timestamp
+	b.MinTimestamp = &minTimestamp
+	b.MaxTimestamp = &maxTimestamp
+}
+
+// BatchSendBundleBefore sends a Bundle concurrently on the connected relay clients whose most recent latency fits
+// before deadline, e.g. the start of the bundle's slot. Relay clients without a recorded latency are always sent to.
+// Skipped relay clients get a response with ErrRelaySkipped, requests still in flight at deadline are aborted.
+// AdaptiveOptions and circuit breakers apply as in BatchSendBundleContext.
+func (r *BatchRelayClient) BatchSendBundleBefore(b Bundle, deadline time.Time) (resps map[string]SendBundleResponse) {
+	remaining := time.Until(deadline)
+	planned, skipped := r.plan()
+	clients := make([]*RelayClient, 0, len(planned))
+	for _, client := range planned {
+		latency, ok := r.Latency(client.Name())
+		if ok && latency > remaining {
+			skipped[client.Name()] = ErrRelaySkipped
+			continue
+		}
+		clients = append(clients, client)
+	}
+
+	resps = r.sendBundleConcurrently(context.Background(), clients, b, deadline)
+	setSkipped(resps, skipped)
+	return
+}
@@ -0,0 +1,75 @@
+package flashbots
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestSlotClock(t *testing.T) {
+	c := MainnetSlotClock()
+
+	// the merge block, 15537394, was proposed in slot 4700013
+	if got := c.SlotOfTimestamp(1663224179); got != 4700013 {
+		t.Errorf("SlotOfTimestamp() = %v, want %v", got, 4700013)
+	}
+	if got := c.SlotTimestamp(4700013); got != 1663224179 {
+		t.Errorf("SlotTimestamp() = %v, want %v", got, 1663224179)
+	}
+
+	c.now = func() time.Time { return time.Unix(1663224179, 0).Add(9 * time.Second) }
+	if got := c.CurrentSlot(); got != 4700013 {
+		t.Errorf("CurrentSlot() = %v, want %v", got, 4700013)
+	}
+	if got := c.TimeUntilNextSlot(); got != 3*time.Second {
+		t.Errorf("TimeUntilNextSlot() = %v, want %v", got, 3*time.Second)
+	}
+	if got := c.TimeUntilSlot(4700013); got != -9*time.Second {
+		t.Errorf("TimeUntilSlot() = %v, want %v", got, -9*time.Second)
+	}
+
+	var b Bundle
+	b.SetSlot(c, c.NextSlot())
+	if *b.MinTimestamp != 1663224191 || *b.MaxTimestamp != 1663224191 {
+		t.Errorf("SetSlot() timestamps = [%v, %v], want 1663224191", *b.MinTimestamp, *b.MaxTimestamp)
+	}
+
+	if _, err := NewSlotClock(time.Unix(0, 0), 1500*time.Millisecond); err == nil {
+		t.Errorf("NewSlotClock() error = nil for a fractional slot duration")
+	}
+}
+
+func TestBatchRelayClient_BatchSendBundleBefore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	fast, _ := NewRelayClient(pkey, "fast", server.URL, "")
+	slow, _ := NewRelayClient(pkey, "slow", server.URL, "")
+	unknown, _ := NewRelayClient(pkey, "unknown", server.URL, "")
+	batch, _ := NewBatchRelayClientFromRelayClients(fast, slow, unknown)
+	batch.record("fast", SendBundleResponse{Duration: 50 * time.Millisecond})
+	batch.record("slow", SendBundleResponse{Duration: 5 * time.Second})
+
+	resps := batch.BatchSendBundleBefore(Bundle{}, time.Now().Add(time.Second))
+	if len(resps) != 3 {
+		t.Fatalf("BatchSendBundleBefore() len = %v, want 3", len(resps))
+	}
+	if !errors.Is(resps["slow"].Error, ErrRelaySkipped) {
+		t.Errorf("slow relay error = %v, want ErrRelaySkipped", resps["slow"].Error)
+	}
+	for _, name := range []string{"fast", "unknown"} {
+		if resps[name].Error != nil || len(resps[name].ResponseBytes) == 0 {
+			t.Errorf("%s relay response = %+v", name, resps[name])
+		}
+	}
+	if latency, ok := batch.Latency("unknown"); !ok || latency == 0 {
+		t.Errorf("Latency() of unknown relay not recorded after sending")
+	}
+}
@@ -0,0 +1,335 @@
+package flashbots
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// statsWindow is the number of most recent requests relay statistics are computed over
+const statsWindow = 128
+
+// ErrRelayUnhealthy is the error of relays skipped because their recent error rate exceeds AdaptiveOptions.MaxErrorRate
+var ErrRelayUnhealthy = errors.New("relay skipped: recent error rate too high")
+
+// RelayStats is a snapshot of the statistics of a relay over its most recent requests
+type RelayStats struct {
+	Name        string
+	Requests    uint64        // Requests is the number of requests sent since the BatchRelayClient was created
+	Errors      uint64        // Errors is the number of failed requests since the BatchRelayClient was created
+	Samples     int           // Samples is the number of recent requests P50, P90, P99 and ErrorRate are computed over
+	ErrorRate   float64       // ErrorRate is the share of recent requests that failed, from 0 to 1
+	LastLatency time.Duration // LastLatency is the duration of the most recent request that reached the relay
+	P50         time.Duration
+	P90         time.Duration
+	P99         time.Duration
+	LastSuccess time.Time // LastSuccess is when the most recent successful request completed, zero if none did
+	LastAttempt time.Time // LastAttempt is when the most recent request completed
+}
+
+// DefaultRetryAfter is the AdaptiveOptions.RetryAfter of relays skipped for their error rate if it is not set
+const DefaultRetryAfter = 30 * time.Second
+
+// AdaptiveOptions makes a BatchRelayClient use the statistics of its relays to order, skip and time out requests.
+// Zero values disable an option.
+type AdaptiveOptions struct {
+	// Order sends to relays by ascending error rate and median latency, relays without statistics first
+	Order bool
+
+	// MaxErrorRate skips relays whose recent error rate is above it once they have MinSamples requests. A skipped
+	// relay is tried again once its last request is older than RetryAfter, DefaultRetryAfter if RetryAfter is 0.
+	MaxErrorRate float64
+	MinSamples   int
+	RetryAfter   time.Duration
+
+	// TimeoutPercentile and TimeoutMultiplier set the timeout of a request to a relay to the given latency percentile
+	// (e.g. 0.99) times TimeoutMultiplier, bounded by MinTimeout and MaxTimeout. MaxTimeout is used for relays
+	// with fewer than MinSamples requests.
+	TimeoutPercentile float64
+	TimeoutMultiplier float64
+	MinTimeout        time.Duration
+	MaxTimeout        time.Duration
+}
+
+type sample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// relayStats holds a ring buffer of the most recent requests of a relay
+type relayStats struct {
+	samples     [statsWindow]sample
+	next        int
+	count       int
+	requests    uint64
+	errors      uint64
+	lastLatency time.Duration
+	lastSuccess time.Time
+	lastAttempt time.Time
+}
+
+func (s *relayStats) add(latency time.Duration, failed bool, at time.Time) {
+	s.samples[s.next] = sample{latency: latency, failed: failed}
+	s.next = (s.next + 1) % statsWindow
+	if s.count < statsWindow {
+		s.count++
+	}
+	s.requests++
+	s.lastAttempt = at
+	if latency != 0 {
+		s.lastLatency = latency
+	}
+	if failed {
+		s.errors++
+	} else {
+		s.lastSuccess = at
+	}
+}
+
+func (s *relayStats) snapshot(name string) (stats RelayStats) {
+	stats = RelayStats{
+		Name:        name,
+		Requests:    s.requests,
+		Errors:      s.errors,
+		Samples:     s.count,
+		LastLatency: s.lastLatency,
+		LastSuccess: s.lastSuccess,
+		LastAttempt: s.lastAttempt,
+	}
+	if s.count == 0 {
+		return
+	}
+
+	latencies := make([]time.Duration, 0, s.count)
+	failed := 0
+	for _, smp := range s.samples[:s.count] {
+		if smp.failed {
+			failed++
+		}
+		if smp.latency != 0 {
+			latencies = append(latencies, smp.latency)
+		}
+	}
+	stats.ErrorRate = float64(failed) / float64(s.count)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	stats.P50 = percentile(latencies, 0.50)
+	stats.P90 = percentile(latencies, 0.90)
+	stats.P99 = percentile(latencies, 0.99)
+	return
+}
+
+// Percentile returns the latency percentile p, from 0 to 1, of the recent requests. It interpolates between P50,
+// P90 and P99 and is 0 without samples.
+func (s RelayStats) Percentile(p float64) time.Duration {
+	switch {
+	case p <= 0.50:
+		return s.P50
+	case p <= 0.90:
+		return s.P50 + time.Duration((p-0.50)/0.40*float64(s.P90-s.P50))
+	case p <= 0.99:
+		return s.P90 + time.Duration((p-0.90)/0.09*float64(s.P99-s.P90))
+	default:
+		return s.P99
+	}
+}
+
+// percentile returns the nearest rank percentile p of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.999999) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// requestFailed is true if the request errored or the relay answered with a JSON-RPC error
+func requestFailed(resp SendBundleResponse) bool {
+	if resp.Error != nil {
+		return true
+	}
+	var envelope rpcResponse
+	if err := json.Unmarshal(resp.ResponseBytes, &envelope); err != nil {
+		return true
+	}
+	return envelope.Error != nil
+}
+
+// sent is false for requests that were never sent, e.g. skipped, rate limited or shadowed relays
+func sent(resp SendBundleResponse) bool {
+	if resp.DryRun {
+		return false
+	}
+	return resp.Duration != 0 || (resp.Error != nil && !errors.Is(resp.Error, ErrRelaySkipped) &&
+		!errors.Is(resp.Error, ErrRelayUnhealthy) && !errors.Is(resp.Error, ErrRateLimited))
+}
+
+// record adds the outcome of a request to the statistics of the relay client called name. Requests that were never
+// sent are ignored.
+func (r *BatchRelayClient) record(name string, resp SendBundleResponse) {
+	if !sent(resp) {
+		return
+	}
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	if r.stats == nil {
+		r.stats = make(map[string]*relayStats)
+	}
+	s, ok := r.stats[name]
+	if !ok {
+		s = &relayStats{}
+		r.stats[name] = s
+	}
+	s.add(resp.Duration, requestFailed(resp), time.Now())
+}
+
+// Latency returns the most recent request duration of the relay client called name, false if there is none
+func (r *BatchRelayClient) Latency(name string) (latency time.Duration, ok bool) {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	s, ok := r.stats[name]
+	if !ok || s.lastLatency == 0 {
+		return 0, false
+	}
+	return s.lastLatency, true
+}
+
+// Stats returns a snapshot of the statistics of every connected relay client by name, including those of nested
+// relays. Relay clients without requests have zero statistics.
+func (r *BatchRelayClient) Stats() (stats map[string]RelayStats) {
+	stats = r.localStats()
+	for _, relay := range r.relays {
+		for name, s := range relay.Stats() {
+			stats[name] = s
+		}
+	}
+	return
+}
+
+// localStats returns the statistics of the relay clients connected to r, without those of nested relays
+func (r *BatchRelayClient) localStats() (stats map[string]RelayStats) {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	stats = make(map[string]RelayStats, len(r.relayClients))
+	for _, client := range r.relayClients {
+		s, ok := r.stats[client.Name()]
+		if !ok {
+			stats[client.Name()] = RelayStats{Name: client.Name()}
+			continue
+		}
+		stats[client.Name()] = s.snapshot(client.Name())
+	}
+	return
+}
+
+// SetAdaptive makes r order, skip and time out requests by relay statistics, nil to send to all relays in order
+// without timeouts
+func (r *BatchRelayClient) SetAdaptive(opts *AdaptiveOptions) {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	r.adaptive = opts
+}
+
+// plan returns the relay clients to send to in order and the error of every relay client skipped, by name.
+// Relay clients are skipped by the AdaptiveOptions and circuit breakers of r, except shadowed ones.
+func (r *BatchRelayClient) plan() (clients []*RelayClient, skipped map[string]error) {
+	stats := r.localStats()
+	r.statsMu.Lock()
+	opts := r.adaptive
+	r.statsMu.Unlock()
+	retryAfter := DefaultRetryAfter
+	if opts != nil && opts.RetryAfter != 0 {
+		retryAfter = opts.RetryAfter
+	}
+
+	clients = make([]*RelayClient, 0, len(r.relayClients))
+	skipped = make(map[string]error)
+	for _, client := range r.relayClients {
+		if _, shadowed := r.shadowed(client.Name()); shadowed {
+			clients = append(clients, client)
+			continue
+		}
+		s := stats[client.Name()]
+		if opts != nil && opts.MaxErrorRate > 0 && s.Samples >= opts.MinSamples && s.ErrorRate > opts.MaxErrorRate &&
+			time.Since(s.LastAttempt) < retryAfter {
+			skipped[client.Name()] = ErrRelayUnhealthy
+			continue
+		}
+		if err := r.allow(client.Name()); err != nil {
+			skipped[client.Name()] = err
+			continue
+		}
+		clients = append(clients, client)
+	}
+
+	if opts != nil && opts.Order {
+		sort.SliceStable(clients, func(i, j int) bool {
+			si, sj := stats[clients[i].Name()], stats[clients[j].Name()]
+			if si.ErrorRate != sj.ErrorRate {
+				return si.ErrorRate < sj.ErrorRate
+			}
+			return si.P50 < sj.P50
+		})
+	}
+	return
+}
+
+// timeout returns the adaptive timeout of requests to the relay client called name, 0 for none
+func (r *BatchRelayClient) timeout(name string) time.Duration {
+	r.statsMu.Lock()
+	opts := r.adaptive
+	var stats RelayStats
+	if s, ok := r.stats[name]; ok {
+		stats = s.snapshot(name)
+	}
+	r.statsMu.Unlock()
+
+	if opts == nil || opts.TimeoutPercentile == 0 {
+		return 0
+	}
+	if stats.Samples < opts.MinSamples || stats.P50 == 0 {
+		return opts.MaxTimeout
+	}
+
+	multiplier := opts.TimeoutMultiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+	timeout := time.Duration(float64(stats.Percentile(opts.TimeoutPercentile)) * multiplier)
+	if timeout < opts.MinTimeout {
+		timeout = opts.MinTimeout
+	}
+	if opts.MaxTimeout != 0 && timeout > opts.MaxTimeout {
+		timeout = opts.MaxTimeout
+	}
+	return timeout
+}
+
+// sendBundleTo sends b to client with its adaptive timeout and deadline, if not zero, and records the outcome in its
+// stats and circuit breaker. Bundles to a shadowed client are only recorded.
+func (r *BatchRelayClient) sendBundleTo(ctx context.Context, client *RelayClient, b Bundle, deadline time.Time) (resp SendBundleResponse) {
+	if opts, shadowed := r.shadowed(client.Name()); shadowed {
+		return r.shadowBundle(opts, client, b)
+	}
+	if timeout := r.timeout(client.Name()); timeout != 0 && (deadline.IsZero() || time.Now().Add(timeout).Before(deadline)) {
+		deadline = time.Now().Add(timeout)
+	}
+	reqCtx := ctx
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	resp = client.sendBundle(reqCtx, b)
+	r.record(client.Name(), resp)
+	// requests that ran into their timeout or deadline failed, only the end of the caller's ctx is not counted
+	r.recordBreaker(ctx, client.Name(), resp)
+	return
+}
@@ -0,0 +1,156 @@
+package flashbots
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+func Test_percentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0.50, 50 * time.Millisecond},
+		{0.90, 90 * time.Millisecond},
+		{0.99, 99 * time.Millisecond},
+		{1, 100 * time.Millisecond},
+		{0, time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile() of no samples = %v, want 0", got)
+	}
+}
+
+func TestBatchRelayClient_Stats(t *testing.T) {
+	ok := []byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`)
+	rpcErr := []byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"bundle too large"}}`)
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	healthy, _ := NewRelayClient(pkey, "healthy", "http://localhost", "")
+	flaky, _ := NewRelayClient(pkey, "flaky", "http://localhost", "")
+	idle, _ := NewRelayClient(pkey, "idle", "http://localhost", "")
+	batch, _ := NewBatchRelayClientFromRelayClients(flaky, idle, healthy)
+
+	for i := 1; i <= 10; i++ {
+		batch.record("healthy", SendBundleResponse{ResponseBytes: ok, Duration: time.Duration(i) * time.Millisecond})
+		resp := SendBundleResponse{ResponseBytes: ok, Duration: 100 * time.Millisecond}
+		if i%2 == 0 {
+			resp.ResponseBytes = rpcErr
+		}
+		batch.record("flaky", resp)
+	}
+	batch.record("idle", SendBundleResponse{Error: ErrRelaySkipped})
+
+	stats := batch.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("Stats() len = %v, want 3", len(stats))
+	}
+	h := stats["healthy"]
+	if h.Requests != 10 || h.Errors != 0 || h.ErrorRate != 0 || h.LastSuccess.IsZero() {
+		t.Errorf("healthy stats = %+v", h)
+	}
+	if h.P50 != 5*time.Millisecond || h.P90 != 9*time.Millisecond || h.P99 != 10*time.Millisecond || h.LastLatency != 10*time.Millisecond {
+		t.Errorf("healthy latencies = %v %v %v %v", h.P50, h.P90, h.P99, h.LastLatency)
+	}
+	if f := stats["flaky"]; f.Errors != 5 || f.ErrorRate != 0.5 {
+		t.Errorf("flaky stats = %+v", f)
+	}
+	if i := stats["idle"]; i.Requests != 0 || i.Samples != 0 {
+		t.Errorf("idle stats = %+v", i)
+	}
+
+	batch.SetAdaptive(&AdaptiveOptions{
+		Order:             true,
+		MaxErrorRate:      0.25,
+		MinSamples:        5,
+		RetryAfter:        time.Minute,
+		TimeoutPercentile: 0.99,
+		TimeoutMultiplier: 2,
+		MinTimeout:        50 * time.Millisecond,
+		MaxTimeout:        time.Second,
+	})
+	clients, skipped := batch.plan()
+	if len(clients) != 2 || clients[0].Name() != "idle" || clients[1].Name() != "healthy" {
+		t.Errorf("plan() clients = %v, want [idle healthy]", clients)
+	}
+	if len(skipped) != 1 || skipped["flaky"] != ErrRelayUnhealthy {
+		t.Errorf("plan() skipped = %v, want [flaky]", skipped)
+	}
+	if got := batch.timeout("healthy"); got != 50*time.Millisecond {
+		t.Errorf("timeout(healthy) = %v, want %v", got, 50*time.Millisecond)
+	}
+	if got := batch.timeout("flaky"); got != 200*time.Millisecond {
+		t.Errorf("timeout(flaky) = %v, want %v", got, 200*time.Millisecond)
+	}
+	if got := batch.timeout("idle"); got != time.Second {
+		t.Errorf("timeout(idle) = %v, want %v", got, time.Second)
+	}
+}
+
+func TestBatchRelayClient_BatchSendBundleContext_adaptive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	good, _ := NewRelayClient(pkey, "good", server.URL, "")
+	bad, _ := NewRelayClient(pkey, "bad", server.URL, "")
+	batch, _ := NewBatchRelayClientFromRelayClients(good, bad)
+	batch.record("bad", SendBundleResponse{Duration: time.Millisecond, Error: errors.New("connection refused")})
+	batch.SetAdaptive(&AdaptiveOptions{MaxErrorRate: 0.5, MinSamples: 1, RetryAfter: time.Minute})
+
+	resps := batch.BatchSendBundleContext(context.Background(), Bundle{})
+	if !errors.Is(resps["bad"].Error, ErrRelayUnhealthy) {
+		t.Errorf("bad relay error = %v, want ErrRelayUnhealthy", resps["bad"].Error)
+	}
+	if resps["good"].Error != nil {
+		t.Errorf("good relay error = %v", resps["good"].Error)
+	}
+	if s := batch.Stats()["good"]; s.Requests != 1 || s.Errors != 0 {
+		t.Errorf("good relay stats = %+v", s)
+	}
+	if s := batch.Stats()["bad"]; s.Requests != 1 {
+		t.Errorf("skipped request was recorded: %+v", s)
+	}
+}
+
+func TestBatchRelayClient_plan_noRetryAfter(t *testing.T) {
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	good, _ := NewRelayClient(pkey, "good", "http://localhost", "")
+	bad, _ := NewRelayClient(pkey, "bad", "http://localhost", "")
+	batch, _ := NewBatchRelayClientFromRelayClients(good, bad)
+	batch.record("bad", SendBundleResponse{Duration: time.Millisecond, Error: errors.New("connection refused")})
+	batch.SetAdaptive(&AdaptiveOptions{MaxErrorRate: 0.5, MinSamples: 1})
+
+	clients, skipped := batch.plan()
+	if len(clients) != 1 || clients[0].Name() != "good" {
+		t.Errorf("plan() clients = %v, want only good", clients)
+	}
+	if !errors.Is(skipped["bad"], ErrRelayUnhealthy) {
+		t.Errorf("plan() bad relay error = %v, want ErrRelayUnhealthy", skipped["bad"])
+	}
+
+	// the bad relay is tried again once DefaultRetryAfter passed
+	batch.statsMu.Lock()
+	batch.stats["bad"].lastAttempt = time.Now().Add(-DefaultRetryAfter)
+	batch.statsMu.Unlock()
+	if clients, _ := batch.plan(); len(clients) != 2 {
+		t.Errorf("plan() clients = %v after DefaultRetryAfter, want both", clients)
+	}
+}
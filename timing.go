@@ -0,0 +1,107 @@
+package flashbots
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestTiming breaks down the duration of a relay request. Phases that did not happen, e.g. DNS and TLS on a
+// reused connection, are 0. It is the Timing of the SendBundleResponse returned by SendBundle, CancelBundle,
+// SimulateBundleContext and GetBundleStatsContext.
+type RequestTiming struct {
+	DNS             time.Duration // DNS is the duration of the DNS lookup
+	Connect         time.Duration // Connect is the duration of the TCP connection
+	TLSHandshake    time.Duration // TLSHandshake is the duration of the TLS handshake
+	RequestWritten  time.Duration // RequestWritten is the time from the start of the request until it was written
+	TimeToFirstByte time.Duration // TimeToFirstByte is the time from the request being written until the first response byte
+	BodyRead        time.Duration // BodyRead is the time from the first response byte until the body was read
+	Total           time.Duration // Total is the duration of the whole request
+	ConnReused      bool          // ConnReused is true if the request was sent on a previously used connection
+}
+
+// timingTrace records a RequestTiming with an httptrace.ClientTrace. The trace hooks may run on other goroutines
+// than the request.
+type timingTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wrote        time.Time
+	firstByte    time.Time
+	timing       RequestTiming
+}
+
+func newTimingTrace() *timingTrace {
+	return &timingTrace{
+		start: time.Now(),
+	}
+}
+
+func (t *timingTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timing.ConnReused = info.Reused
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timing.DNS = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.connectStart = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timing.Connect = time.Since(t.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timing.TLSHandshake = time.Since(t.tlsStart)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.wrote = time.Now()
+			t.timing.RequestWritten = t.wrote.Sub(t.start)
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.firstByte = time.Now()
+			if !t.wrote.IsZero() {
+				t.timing.TimeToFirstByte = t.firstByte.Sub(t.wrote)
+			}
+		},
+	}
+}
+
+// finish returns the timing of a request whose body was read just now
+func (t *timingTrace) finish() RequestTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if !t.firstByte.IsZero() {
+		t.timing.BodyRead = now.Sub(t.firstByte)
+	}
+	t.timing.Total = now.Sub(t.start)
+	return t.timing
+}
@@ -0,0 +1,52 @@
+package flashbots
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestRelayClient_SendBundle_timing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	r, _ := NewRelayClient(pkey, "relay", server.URL, "")
+
+	first := r.SendBundle(Bundle{})
+	if first.Error != nil {
+		t.Fatalf("SendBundle() error = %v", first.Error)
+	}
+	second := r.SendBundle(Bundle{})
+	if second.Error != nil {
+		t.Fatalf("SendBundle() error = %v", second.Error)
+	}
+
+	for _, resp := range []SendBundleResponse{first, second} {
+		timing := resp.Timing
+		if timing.Total <= 0 || timing.Total > resp.Duration {
+			t.Errorf("Timing.Total = %v, want within Duration %v", timing.Total, resp.Duration)
+		}
+		if resp.Endpoint != server.URL {
+			t.Errorf("Endpoint = %v, want %v", resp.Endpoint, server.URL)
+		}
+		if timing.RequestWritten <= 0 || timing.TimeToFirstByte < 10*time.Millisecond {
+			t.Errorf("Timing = %+v, want request written and time to first byte of at least 10ms", timing)
+		}
+		if timing.RequestWritten+timing.TimeToFirstByte+timing.BodyRead > timing.Total {
+			t.Errorf("Timing = %+v, phases exceed total", timing)
+		}
+	}
+	if first.Timing.ConnReused || first.Timing.Connect <= 0 {
+		t.Errorf("first Timing = %+v, want a new connection", first.Timing)
+	}
+	if !second.Timing.ConnReused || second.Timing.Connect != 0 {
+		t.Errorf("second Timing = %+v, want a reused connection", second.Timing)
+	}
+}
@@ -0,0 +1,136 @@
+package txbuilder
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/wphan/go-flashbots"
+	"github.com/wphan/go-flashbots/pricing"
+)
+
+// Payment describes a transaction paying the block builder. The amount is sent as the transaction value, either
+// directly to a known fee recipient, or to a contract that forwards msg.value to block.coinbase.
+type Payment struct {
+	Key  *ecdsa.PrivateKey // Key signs the payment, nil to use the Builder's key
+	To   common.Address    // To is the fee recipient or the pay coinbase contract
+	Data []byte            // Data is the calldata of the pay coinbase contract call, nil for a plain transfer
+	Gas  uint64            // Gas limit of the payment, 0 for 21000, the gas of a plain transfer
+
+	// GasUsed is the gas the payment uses, e.g. from a simulation of the pay coinbase contract call, 0 for the Gas
+	// limit. It is used to compute the amount for a target effective gas price, which overpays by the unused gas
+	// if it is above the gas actually used.
+	GasUsed uint64
+
+	// Fees of the payment, nil to use the Builder's GasFeeCap with no priority fee. A priority fee is paid on top
+	// of the amount and is not included when computing the amount for a target effective gas price.
+	Fees *Fees
+}
+
+// AppendPayment signs a payment of amount and appends it to bundle. The nonce follows the last transaction of the
+// payment's sender in bundle, or is its pending nonce if it has none.
+func (b *Builder) AppendPayment(ctx context.Context, bundle *flashbots.Bundle, p Payment, amount *big.Int) (tx *types.Transaction, retErr error) {
+	if amount == nil || amount.Sign() < 0 {
+		retErr = errors.New("must provide a non negative amount")
+		return
+	}
+	key := p.Key
+	if key == nil {
+		key = b.key
+	}
+	if key == nil {
+		retErr = errors.New("no key for payment")
+		return
+	}
+	fees := p.Fees
+	if fees == nil {
+		fees = &Fees{GasTipCap: new(big.Int), GasFeeCap: b.Fees().GasFeeCap}
+	}
+	if err := fees.validate(); err != nil {
+		retErr = fmt.Errorf("invalid payment fees: %w", err)
+		return
+	}
+
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := b.nextNonce(ctx, bundle, sender)
+	if err != nil {
+		retErr = err
+		return
+	}
+
+	to := p.To
+	tx, err = types.SignNewTx(key, b.signer, &types.DynamicFeeTx{
+		ChainID:   b.chainID,
+		Nonce:     nonce,
+		GasTipCap: fees.GasTipCap,
+		GasFeeCap: fees.GasFeeCap,
+		Gas:       p.gasLimit(),
+		To:        &to,
+		Value:     new(big.Int).Set(amount),
+		Data:      p.Data,
+	})
+	if err != nil {
+		retErr = fmt.Errorf("failed to sign payment: %w", err)
+		return
+	}
+
+	retErr = bundle.AddTransaction(tx)
+	return
+}
+
+// AppendPaymentForGasPrice appends a payment that raises the effective gas price of bundle to target. current is
+// what bundle pays without the payment, e.g. from pricing.FromSimulation. The payment may be of 0 if bundle already
+// reaches target.
+func (b *Builder) AppendPaymentForGasPrice(ctx context.Context, bundle *flashbots.Bundle, p Payment,
+	current pricing.BundlePayment, target *big.Int) (tx *types.Transaction, retErr error) {
+
+	amount := current.TransferForEffectiveGasPrice(target, p.gasUsed())
+	return b.AppendPayment(ctx, bundle, p, amount)
+}
+
+// gasLimit is the gas limit of p, the gas of a plain transfer if it has none
+func (p Payment) gasLimit() uint64 {
+	if p.Gas == 0 {
+		return params.TxGas
+	}
+	return p.Gas
+}
+
+// gasUsed is the gas p is expected to use
+func (p Payment) gasUsed() uint64 {
+	if p.GasUsed != 0 {
+		return p.GasUsed
+	}
+	return p.gasLimit()
+}
+
+// nextNonce is the nonce of the next transaction of sender in bundle
+func (b *Builder) nextNonce(ctx context.Context, bundle *flashbots.Bundle, sender common.Address) (nonce uint64, retErr error) {
+	found := false
+	for _, tx := range bundle.Transactions {
+		from, err := types.Sender(b.signer, tx)
+		if err != nil {
+			retErr = fmt.Errorf("failed to get sender of tx %s: %w", tx.Hash().Hex(), err)
+			return
+		}
+		if from == sender && (!found || tx.Nonce() >= nonce) {
+			nonce = tx.Nonce() + 1
+			found = true
+		}
+	}
+	if found {
+		return
+	}
+
+	nonce, retErr = b.nonces.PendingNonceAt(ctx, sender)
+	if retErr != nil {
+		retErr = fmt.Errorf("failed to get nonce of %s: %w", sender.Hex(), retErr)
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package txbuilder
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/wphan/go-flashbots/account"
+	"github.com/wphan/go-flashbots/pricing"
+)
+
+func TestBuilder_AppendPaymentForGasPrice(t *testing.T) {
+	pkey, pubAddr, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	builder, err := NewBuilder(big.NewInt(1), pkey, NonceMap{pubAddr: 3}, Fees{
+		GasTipCap: big.NewInt(2 * params.GWei),
+		GasFeeCap: big.NewInt(50 * params.GWei),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := builder.Build(context.Background(), Template{Requests: []TxRequest{{To: &pubAddr, Gas: 100000}}}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := pricing.FromGasEstimates(b, big.NewInt(10*params.GWei), []uint64{79000}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feeRecipient := common.HexToAddress("0xdafea492d9c6733ae3d56b7ed1adb60692c98bc5")
+	tx, err := builder.AppendPaymentForGasPrice(context.Background(), &b, Payment{To: feeRecipient, Gas: 21000}, current, big.NewInt(100*params.GWei))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 100 gwei over 100000 gas, minus the 2 gwei priority fee of the first transaction
+	wantAmount := big.NewInt(100*params.GWei*100000 - 2*params.GWei*79000)
+	if tx.Value().Cmp(wantAmount) != 0 {
+		t.Errorf("payment value = %v, want %v", tx.Value(), wantAmount)
+	}
+	if tx.Nonce() != 4 || tx.GasTipCap().Sign() != 0 || *tx.To() != feeRecipient {
+		t.Errorf("payment nonce %d, tip %v, to %s", tx.Nonce(), tx.GasTipCap(), tx.To().Hex())
+	}
+	if len(b.Transactions) != 2 || len(b.TxsByteString) != 2 || b.Transactions[1] != tx {
+		t.Fatalf("payment was not appended to the bundle")
+	}
+	txBytes, _ := tx.MarshalBinary()
+	if b.TxsByteString[1] != "0x"+common.Bytes2Hex(txBytes) {
+		t.Errorf("TxsByteString not in sync with Transactions")
+	}
+
+	// a sender without transactions in the bundle uses its pending nonce
+	otherKey, _, _ := account.LoadPrivateKeyString("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	tx, err = builder.AppendPayment(context.Background(), &b, Payment{Key: otherKey, To: feeRecipient, Data: common.FromHex("0x1b9265b8"), Gas: 30000}, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Nonce() != 0 || len(tx.Data()) != 4 {
+		t.Errorf("payment nonce %d, data %x", tx.Nonce(), tx.Data())
+	}
+}
+
+func TestBuilder_AppendPaymentForGasPrice_gas(t *testing.T) {
+	pkey, pubAddr, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	builder, err := NewBuilder(big.NewInt(1), pkey, NonceMap{pubAddr: 3}, Fees{
+		GasTipCap: big.NewInt(2 * params.GWei),
+		GasFeeCap: big.NewInt(50 * params.GWei),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feeRecipient := common.HexToAddress("0xdafea492d9c6733ae3d56b7ed1adb60692c98bc5")
+
+	tests := []struct {
+		name       string
+		payment    Payment
+		wantGas    uint64
+		wantAmount *big.Int
+	}{
+		{
+			name:       "default gas of a plain transfer",
+			payment:    Payment{To: feeRecipient},
+			wantGas:    21000,
+			wantAmount: big.NewInt(100*params.GWei*100000 - 2*params.GWei*79000),
+		},
+		{
+			name:       "contract payment priced by gas used",
+			payment:    Payment{To: feeRecipient, Data: common.FromHex("0x1b9265b8"), Gas: 50000, GasUsed: 30000},
+			wantGas:    50000,
+			wantAmount: big.NewInt(100*params.GWei*109000 - 2*params.GWei*79000),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := builder.Build(context.Background(), Template{Requests: []TxRequest{{To: &pubAddr, Gas: 100000}}}, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			current, err := pricing.FromGasEstimates(b, big.NewInt(10*params.GWei), []uint64{79000}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx, err := builder.AppendPaymentForGasPrice(context.Background(), &b, tt.payment, current, big.NewInt(100*params.GWei))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tx.Gas() != tt.wantGas {
+				t.Errorf("payment gas limit = %d, want %d", tx.Gas(), tt.wantGas)
+			}
+			if tx.Value().Cmp(tt.wantAmount) != 0 {
+				t.Errorf("payment value = %v, want %v", tx.Value(), tt.wantAmount)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Package txbuilder assembles signed dynamic fee transactions into bundles, assigning sequential nonces per sender
+// and re-signing the transactions whenever a bundle is rebuilt for another block.
+package txbuilder
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wphan/go-flashbots"
+)
+
+// NonceSource returns the next nonce of an account. *ethclient.Client satisfies NonceSource.
+type NonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceMap is a NonceSource of accounts with known nonces, accounts missing from the map have nonce 0
+type NonceMap map[common.Address]uint64
+
+func (n NonceMap) PendingNonceAt(_ context.Context, account common.Address) (uint64, error) {
+	return n[account], nil
+}
+
+// Fees are the EIP-1559 fee caps of a transaction
+type Fees struct {
+	GasTipCap *big.Int // GasTipCap is the max priority fee per gas
+	GasFeeCap *big.Int // GasFeeCap is the max fee per gas, base fee included
+}
+
+// TxRequest describes a transaction of a bundle before it is signed
+type TxRequest struct {
+	Key        *ecdsa.PrivateKey // Key signs the transaction, nil to use the Builder's key
+	To         *common.Address   // To is the recipient, nil for contract creation
+	Value      *big.Int
+	Data       []byte
+	Gas        uint64
+	AccessList types.AccessList
+	Fees       *Fees // Fees of the transaction, nil to use the Builder's fees
+	CanRevert  bool  // CanRevert adds the transaction to the bundle's RevertingTxHashes
+}
+
+// Template is a bundle before its transactions are signed. A Template can be built for any number of blocks.
+type Template struct {
+	Requests     []TxRequest
+	MinTimestamp *int
+	MaxTimestamp *int
+}
+
+type Builder struct {
+	chainID *big.Int
+	signer  types.Signer
+	key     *ecdsa.PrivateKey
+	nonces  NonceSource
+
+	mu   sync.RWMutex
+	fees Fees
+}
+
+// NewBuilder creates a new transaction builder
+// chainID: chain ID transactions are signed for
+// key:     default key transactions are signed with, may be nil if every TxRequest has a Key
+// nonces:  source of the first nonce of each sender in a bundle
+// fees:    default fee caps of transactions
+func NewBuilder(chainID *big.Int, key *ecdsa.PrivateKey, nonces NonceSource, fees Fees) (b *Builder, retErr error) {
+	if chainID == nil {
+		retErr = errors.New("must provide a chainID")
+		return
+	}
+	if nonces == nil {
+		retErr = errors.New("must provide a nonce source")
+		return
+	}
+	if err := fees.validate(); err != nil {
+		retErr = err
+		return
+	}
+	b = &Builder{
+		chainID: chainID,
+		signer:  types.NewLondonSigner(chainID),
+		key:     key,
+		nonces:  nonces,
+		fees:    fees,
+	}
+	return
+}
+
+// SetFees changes the default fee caps of transactions built after the call, e.g. to follow the base fee when a
+// bundle is rebuilt for the next block
+func (b *Builder) SetFees(fees Fees) error {
+	if err := fees.validate(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fees = fees
+	return nil
+}
+
+func (b *Builder) Fees() Fees {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.fees
+}
+
+func (f Fees) validate() error {
+	if f.GasTipCap == nil || f.GasFeeCap == nil {
+		return errors.New("must provide GasTipCap and GasFeeCap")
+	}
+	if f.GasFeeCap.Cmp(f.GasTipCap) < 0 {
+		return fmt.Errorf("GasFeeCap %s below GasTipCap %s", f.GasFeeCap, f.GasTipCap)
+	}
+	return nil
+}
+
+// Build signs the transactions of t and creates a bundle for blockNumber. Nonces of each sender start at its pending
+// nonce from the NonceSource and increase in the order of t.Requests. Build again to re-sign t for another block.
+func (b *Builder) Build(ctx context.Context, t Template, blockNumber uint64) (bundle flashbots.Bundle, retErr error) {
+	defaultFees := b.Fees()
+	nonces := make(map[common.Address]uint64)
+	txs := make([]*types.Transaction, len(t.Requests))
+	revertingTxHashes := make([]common.Hash, 0)
+	for i, req := range t.Requests {
+		key := req.Key
+		if key == nil {
+			key = b.key
+		}
+		if key == nil {
+			retErr = fmt.Errorf("no key for tx %d", i)
+			return
+		}
+		fees := defaultFees
+		if req.Fees != nil {
+			if err := req.Fees.validate(); err != nil {
+				retErr = fmt.Errorf("invalid fees for tx %d: %w", i, err)
+				return
+			}
+			fees = *req.Fees
+		}
+
+		sender := crypto.PubkeyToAddress(key.PublicKey)
+		nonce, ok := nonces[sender]
+		if !ok {
+			var err error
+			nonce, err = b.nonces.PendingNonceAt(ctx, sender)
+			if err != nil {
+				retErr = fmt.Errorf("failed to get nonce of %s: %w", sender.Hex(), err)
+				return
+			}
+		}
+		nonces[sender] = nonce + 1
+
+		value := req.Value
+		if value == nil {
+			value = new(big.Int)
+		}
+		tx, err := types.SignNewTx(key, b.signer, &types.DynamicFeeTx{
+			ChainID:    b.chainID,
+			Nonce:      nonce,
+			GasTipCap:  fees.GasTipCap,
+			GasFeeCap:  fees.GasFeeCap,
+			Gas:        req.Gas,
+			To:         req.To,
+			Value:      value,
+			Data:       req.Data,
+			AccessList: req.AccessList,
+		})
+		if err != nil {
+			retErr = fmt.Errorf("failed to sign tx %d: %w", i, err)
+			return
+		}
+		txs[i] = tx
+		if req.CanRevert {
+			revertingTxHashes = append(revertingTxHashes, tx.Hash())
+		}
+	}
+
+	return flashbots.NewBundle(txs, blockNumber, 0, t.MinTimestamp, t.MaxTimestamp, revertingTxHashes)
+}
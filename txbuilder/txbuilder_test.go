@@ -0,0 +1,87 @@
+package txbuilder
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	pkey, pubAddr, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	otherKey, otherAddr, _ := account.LoadPrivateKeyString("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	chainID := big.NewInt(1)
+	builder, err := NewBuilder(chainID, pkey, NonceMap{pubAddr: 7}, Fees{
+		GasTipCap: big.NewInt(2 * params.GWei),
+		GasFeeCap: big.NewInt(50 * params.GWei),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := Template{
+		Requests: []TxRequest{
+			{To: &otherAddr, Gas: 21000},
+			{Key: otherKey, To: &pubAddr, Gas: 21000, CanRevert: true},
+			{To: &otherAddr, Gas: 21000, Fees: &Fees{GasTipCap: big.NewInt(0), GasFeeCap: big.NewInt(60 * params.GWei)}},
+		},
+	}
+	b, err := builder.Build(context.Background(), template, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer := types.LatestSignerForChainID(chainID)
+	wantSenders := []struct {
+		from  string
+		nonce uint64
+	}{
+		{pubAddr.Hex(), 7},
+		{otherAddr.Hex(), 0},
+		{pubAddr.Hex(), 8},
+	}
+	for i, tx := range b.Transactions {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from.Hex() != wantSenders[i].from || tx.Nonce() != wantSenders[i].nonce {
+			t.Errorf("tx %d from %s nonce %d, want from %s nonce %d", i, from.Hex(), tx.Nonce(), wantSenders[i].from, wantSenders[i].nonce)
+		}
+		if tx.Type() != types.DynamicFeeTxType {
+			t.Errorf("tx %d type = %d, want %d", i, tx.Type(), types.DynamicFeeTxType)
+		}
+	}
+	if b.Transactions[2].GasFeeCap().Cmp(big.NewInt(60*params.GWei)) != 0 {
+		t.Errorf("tx 2 GasFeeCap = %v, want request fees", b.Transactions[2].GasFeeCap())
+	}
+	if len(b.RevertingTxHashes) != 1 || b.RevertingTxHashes[0] != b.Transactions[1].Hash().Hex() {
+		t.Errorf("RevertingTxHashes = %v, want [%s]", b.RevertingTxHashes, b.Transactions[1].Hash().Hex())
+	}
+	if b.BlockNumber != "0x64" {
+		t.Errorf("BlockNumber = %v, want 0x64", b.BlockNumber)
+	}
+
+	// rebuilding for the next block with higher fees re-signs every transaction
+	err = builder.SetFees(Fees{GasTipCap: big.NewInt(3 * params.GWei), GasFeeCap: big.NewInt(55 * params.GWei)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := builder.Build(context.Background(), template, 101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Transactions[0].Hash() == b.Transactions[0].Hash() || next.Transactions[0].GasTipCap().Cmp(big.NewInt(3*params.GWei)) != 0 {
+		t.Errorf("rebuilt tx 0 was not re-signed with the new fees")
+	}
+	if next.RevertingTxHashes[0] != next.Transactions[1].Hash().Hex() {
+		t.Errorf("rebuilt RevertingTxHashes = %v, want [%s]", next.RevertingTxHashes, next.Transactions[1].Hash().Hex())
+	}
+
+	if err = builder.SetFees(Fees{GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(1)}); err == nil {
+		t.Errorf("SetFees() error = nil, want GasFeeCap below GasTipCap error")
+	}
+}
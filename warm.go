@@ -0,0 +1,124 @@
+package flashbots
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// warmMethod is the JSON-RPC method of keep-warm requests. Relays that do not support it answer with an error,
+// which still keeps the connection open.
+const warmMethod = "eth_blockNumber"
+
+// DefaultWarmInterval refreshes connections well before the 90s idle timeout of http.DefaultTransport
+const DefaultWarmInterval = 30 * time.Second
+
+func (r *RelayClient) prepareWarmPayload() (payloadBytes []byte, retErr error) {
+	payload := rpcPaylod{
+		JsonRPC: "2.0",
+		ID:      1,
+		Method:  warmMethod,
+		Params:  []interface{}{},
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		retErr = err
+		return
+	}
+	return
+}
+
+// Warm opens a connection to every main and simulation endpoint of r with a lightweight request, so the next
+// bundle does not pay for the TCP and TLS handshakes. The responses are discarded, an HTTP error status still warms
+// the connection. retErr is the first failure.
+func (r *RelayClient) Warm(ctx context.Context) (retErr error) {
+	payload, err := r.prepareWarmPayload()
+	if err != nil {
+		retErr = err
+		return
+	}
+
+	warmed := make(map[string]bool)
+	for _, endpoint := range append(append([]string{}, r.mainEndpoints...), r.simulationEndpoints...) {
+		if warmed[endpoint] {
+			continue
+		}
+		warmed[endpoint] = true
+		_, _, err = r.fbRequest(ctx, endpoint, payload)
+		var statusErr *StatusError
+		if err != nil && !errors.As(err, &statusErr) && retErr == nil {
+			retErr = fmt.Errorf("failed to warm %s: %w", endpoint, err)
+		}
+	}
+	return
+}
+
+// KeepWarm warms r immediately and then every interval until ctx is done, keeping idle connections from being
+// closed between bundles. It blocks, run it in its own goroutine. interval 0 uses DefaultWarmInterval.
+// onError is called with every failed warm-up, may be nil.
+func (r *RelayClient) KeepWarm(ctx context.Context, interval time.Duration, onError func(error)) {
+	keepWarm(ctx, interval, func() {
+		if err := r.Warm(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+	})
+}
+
+// Warm warms all connected relay clients concurrently, returning the error of every relay client that failed by
+// name
+func (r *BatchRelayClient) Warm(ctx context.Context) (errs map[string]error) {
+	errs = make(map[string]error)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, client := range r.relayClients {
+		wg.Add(1)
+		go func(client *RelayClient) {
+			defer wg.Done()
+			if err := client.Warm(ctx); err != nil {
+				mu.Lock()
+				errs[client.Name()] = err
+				mu.Unlock()
+			}
+		}(client)
+	}
+	wg.Wait()
+
+	return
+}
+
+// KeepWarm warms all connected relay clients immediately and then every interval until ctx is done. It blocks, run
+// it in its own goroutine. interval 0 uses DefaultWarmInterval. onError is called with the name and error of every
+// failed warm-up, may be nil.
+func (r *BatchRelayClient) KeepWarm(ctx context.Context, interval time.Duration, onError func(name string, err error)) {
+	keepWarm(ctx, interval, func() {
+		errs := r.Warm(ctx)
+		if onError == nil || ctx.Err() != nil {
+			return
+		}
+		for name, err := range errs {
+			onError(name, err)
+		}
+	})
+}
+
+func keepWarm(ctx context.Context, interval time.Duration, warm func()) {
+	if interval <= 0 {
+		interval = DefaultWarmInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		warm()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package flashbots
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wphan/go-flashbots/account"
+)
+
+func TestRelayClient_KeepWarm(t *testing.T) {
+	// KeepWarm runs until the server received 4 rounds of warm-ups after the first Warm
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var warmRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var payload rpcPaylod
+		_ = json.NewDecoder(req.Body).Decode(&payload)
+		if payload.Method == warmMethod {
+			if atomic.AddInt32(&warmRequests, 1) == 10 {
+				cancel()
+			}
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0d1b53154e2910960564190ad0c5ef34c49befb865e3d56374adbf2b1160aa65"}}`))
+	}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	r, _ := NewRelayClient(pkey, "relay", server.URL, server.URL+"/sim")
+
+	if err := r.Warm(context.Background()); err != nil {
+		t.Fatalf("Warm() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&warmRequests); got != 2 {
+		t.Errorf("Warm() requests = %v, want one per endpoint", got)
+	}
+
+	resp := r.SendBundle(Bundle{})
+	if resp.Error != nil || !resp.Timing.ConnReused {
+		t.Errorf("SendBundle() after warming = %+v, want a reused connection", resp)
+	}
+
+	r.KeepWarm(ctx, time.Millisecond, func(err error) { t.Errorf("KeepWarm() error = %v", err) })
+	if got := atomic.LoadInt32(&warmRequests); got < 10 {
+		t.Errorf("KeepWarm() requests = %v, want at least 10", got)
+	}
+}
+
+func TestBatchRelayClient_Warm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	pkey, _, _ := account.LoadPrivateKeyString("0x9c03d71f2cab3ac367e407e25ed213c56b50957a1f75d9f6b4f9be00066d6963")
+	up, _ := NewRelayClient(pkey, "up", server.URL, "")
+	down, _ := NewRelayClient(pkey, "down", "http://127.0.0.1:1", "")
+	batch, _ := NewBatchRelayClientFromRelayClients(up, down)
+
+	errs := batch.Warm(context.Background())
+	if len(errs) != 1 || errs["down"] == nil {
+		t.Errorf("Warm() errors = %v, want only down", errs)
+	}
+	if s := batch.Stats()["up"]; s.Requests != 0 {
+		t.Errorf("warm-up requests were recorded in stats: %+v", s)
+	}
+}